@@ -2,11 +2,11 @@ package datastore
 
 import (
 	"context"
-	"slices"
 	"strings"
 
 	"github.com/PretendoNetwork/nex-go/v2"
 	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	datastore "github.com/PretendoNetwork/nex-protocols-go/v2/datastore"
 	datastore_types "github.com/PretendoNetwork/nex-protocols-go/v2/datastore/types"
 	"github.com/minio/minio-go/v7"
@@ -15,13 +15,15 @@ import (
 type CommonProtocol struct {
 	endpoint                                     nex.EndpointInterface
 	protocol                                     datastore.Interface
+	metrics                                      *common_globals.Metrics
 	S3Bucket                                     string
 	s3DataKeyBase                                string
 	s3NotifyKeyBase                              string
 	RootCACert                                   []byte
 	minIOClient                                  *minio.Client
 	S3Presigner                                  S3PresignerInterface
-	GetUserFriendPIDs                            func(pid uint32) []uint32
+	GetUserFriendPIDs                            func(pid uint32) []uint32 // * Legacy handler, only used if GetUserFriendPIDsByPID is not set
+	GetUserFriendPIDsByPID                       func(pid *types.PID) []*types.PID
 	GetObjectInfoByDataID                        func(dataID *types.PrimitiveU64) (*datastore_types.DataStoreMetaInfo, *nex.Error)
 	UpdateObjectPeriodByDataIDWithPassword       func(dataID *types.PrimitiveU64, dataType *types.PrimitiveU16, password *types.PrimitiveU64) *nex.Error
 	UpdateObjectMetaBinaryByDataIDWithPassword   func(dataID *types.PrimitiveU64, metaBinary *types.QBuffer, password *types.PrimitiveU64) *nex.Error
@@ -84,10 +86,19 @@ func (c *CommonProtocol) VerifyObjectPermission(ownerPID, accessorPID *types.PID
 
 	// * Allow only friends of the owner
 	if permission.Permission.Value == 1 {
-		// TODO - This assumes a legacy client. Will not work on the Switch
-		friendsList := c.GetUserFriendPIDs(ownerPID.LegacyValue())
+		getUserFriendPIDs := c.GetUserFriendPIDsByPID
+		if getUserFriendPIDs == nil && c.GetUserFriendPIDs != nil {
+			getUserFriendPIDs = common_globals.LegacyGetUserFriendPIDs(c.GetUserFriendPIDs)
+		}
+
+		if getUserFriendPIDs == nil {
+			common_globals.Logger.Warning("GetUserFriendPIDsByPID not defined")
+			return nex.NewError(nex.ResultCodes.Core.NotImplemented, "change_error")
+		}
 
-		if !slices.Contains(friendsList, accessorPID.LegacyValue()) {
+		friendsList := getUserFriendPIDs(ownerPID)
+
+		if !common_globals.ContainsPID(friendsList, accessorPID) {
 			return nex.NewError(nex.ResultCodes.DataStore.PermissionDenied, "change_error")
 		}
 	}
@@ -131,11 +142,17 @@ func (c *CommonProtocol) SetMinIOClient(client *minio.Client) {
 	c.S3Presigner = NewS3Presigner(c.minIOClient)
 }
 
+// SetMetricsRecorder sets the MetricsRecorder receiving the metrics of the handlers. Passing nil disables them
+func (c *CommonProtocol) SetMetricsRecorder(recorder common_globals.MetricsRecorder) {
+	c.metrics.SetRecorder(recorder)
+}
+
 // NewCommonProtocol returns a new CommonProtocol
 func NewCommonProtocol(protocol datastore.Interface) *CommonProtocol {
 	commonProtocol := &CommonProtocol{
 		endpoint:   protocol.Endpoint(),
 		protocol:   protocol,
+		metrics:    common_globals.NewMetrics(),
 		RootCACert: []byte{},
 		S3GetRequestHeaders: func() ([]*datastore_types.DataStoreKeyValue, *nex.Error) {
 			return []*datastore_types.DataStoreKeyValue{}, nil
@@ -145,19 +162,19 @@ func NewCommonProtocol(protocol datastore.Interface) *CommonProtocol {
 		},
 	}
 
-	protocol.SetHandlerDeleteObject(commonProtocol.deleteObject)
-	protocol.SetHandlerGetMeta(commonProtocol.getMeta)
-	protocol.SetHandlerGetMetas(commonProtocol.getMetas)
-	protocol.SetHandlerSearchObject(commonProtocol.searchObject)
-	protocol.SetHandlerRateObject(commonProtocol.rateObject)
-	protocol.SetHandlerPostMetaBinary(commonProtocol.postMetaBinary)
-	protocol.SetHandlerPreparePostObject(commonProtocol.preparePostObject)
-	protocol.SetHandlerPrepareGetObject(commonProtocol.prepareGetObject)
-	protocol.SetHandlerCompletePostObject(commonProtocol.completePostObject)
-	protocol.SetHandlerGetMetasMultipleParam(commonProtocol.getMetasMultipleParam)
-	protocol.SetHandlerCompletePostObjects(commonProtocol.completePostObjects)
-	protocol.SetHandlerChangeMeta(commonProtocol.changeMeta)
-	protocol.SetHandlerRateObjects(commonProtocol.rateObjects)
+	protocol.SetHandlerDeleteObject(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.deleteObject))
+	protocol.SetHandlerGetMeta(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.getMeta))
+	protocol.SetHandlerGetMetas(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.getMetas))
+	protocol.SetHandlerSearchObject(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.searchObject))
+	protocol.SetHandlerRateObject(common_globals.MeasureHandler3(commonProtocol.metrics, commonProtocol.rateObject))
+	protocol.SetHandlerPostMetaBinary(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.postMetaBinary))
+	protocol.SetHandlerPreparePostObject(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.preparePostObject))
+	protocol.SetHandlerPrepareGetObject(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.prepareGetObject))
+	protocol.SetHandlerCompletePostObject(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.completePostObject))
+	protocol.SetHandlerGetMetasMultipleParam(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.getMetasMultipleParam))
+	protocol.SetHandlerCompletePostObjects(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.completePostObjects))
+	protocol.SetHandlerChangeMeta(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.changeMeta))
+	protocol.SetHandlerRateObjects(common_globals.MeasureHandler4(commonProtocol.metrics, commonProtocol.rateObjects))
 
 	return commonProtocol
 }
@@ -0,0 +1,241 @@
+package common_globals
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+)
+
+// AdminGatheringMember is a participant or spectator of a gathering, as listed by the admin API
+type AdminGatheringMember struct {
+	PID          uint64 `json:"pid"`
+	ConnectionID uint32 `json:"connection_id"`
+	Connected    bool   `json:"connected"`
+	Role         string `json:"role"`
+}
+
+// AdminGathering is a gathering, as listed by the admin API
+type AdminGathering struct {
+	GatheringID         uint32                  `json:"gathering_id"`
+	OwnerPID            uint64                  `json:"owner_pid"`
+	HostPID             uint64                  `json:"host_pid"`
+	GameMode            uint32                  `json:"game_mode"`
+	MinimumParticipants uint16                  `json:"minimum_participants"`
+	MaximumParticipants uint16                  `json:"maximum_participants"`
+	ParticipationCount  uint32                  `json:"participation_count"`
+	OpenParticipation   bool                    `json:"open_participation"`
+	UserPasswordSet     bool                    `json:"user_password_set"`
+	SystemPasswordSet   bool                    `json:"system_password_set"`
+	Attributes          []uint32                `json:"attributes"`
+	ProgressScore       uint8                   `json:"progress_score"`
+	ApplicationBuffer   []byte                  `json:"application_buffer"`
+	Members             []*AdminGatheringMember `json:"members"`
+	BannedPIDs          []uint64                `json:"banned_pids"`
+}
+
+type adminError struct {
+	Error      string `json:"error"`
+	ResultCode uint32 `json:"result_code,omitempty"`
+}
+
+// adminHandler serves the admin API of a MatchmakingManager
+type adminHandler struct {
+	manager *MatchmakingManager
+}
+
+// NewAdminHandler returns an http.Handler with a JSON API to inspect and manage the gatherings of the manager.
+//
+// The following routes are served, relative to where the handler is mounted:
+//
+//	GET  /gatherings                      lists every gathering
+//	GET  /gatherings/{gid}                returns a single gathering
+//	POST /gatherings/{gid}/close          closes the participation of the gathering
+//	POST /gatherings/{gid}/kick?pid={pid} kicks a participant or spectator. Add &ban=true to also ban them
+//	POST /gatherings/{gid}/unregister     unregisters the gathering, notifying its participants
+//
+// The handler doesn't do any authentication, so it must not be exposed publicly as is.
+// Use http.StripPrefix to mount it under a path
+func NewAdminHandler(manager *MatchmakingManager) http.Handler {
+	return &adminHandler{manager: manager}
+}
+
+func (ah *adminHandler) newAdminGatheringImpl(session *CommonMatchmakeSession) *AdminGathering {
+	matchmakeSession := session.GameMatchmakeSession
+
+	gathering := &AdminGathering{
+		GatheringID:         matchmakeSession.Gathering.ID.Value,
+		OwnerPID:            matchmakeSession.Gathering.OwnerPID.Value(),
+		HostPID:             matchmakeSession.Gathering.HostPID.Value(),
+		GameMode:            matchmakeSession.GameMode.Value,
+		MinimumParticipants: matchmakeSession.Gathering.MinimumParticipants.Value,
+		MaximumParticipants: matchmakeSession.Gathering.MaximumParticipants.Value,
+		ParticipationCount:  matchmakeSession.ParticipationCount.Value,
+		OpenParticipation:   matchmakeSession.OpenParticipation.Value,
+		UserPasswordSet:     session.UserPassword != "",
+		SystemPasswordSet:   session.SystemPassword != "",
+		Attributes:          make([]uint32, 0, matchmakeSession.Attributes.Length()),
+		ProgressScore:       matchmakeSession.ProgressScore.Value,
+		ApplicationBuffer:   matchmakeSession.ApplicationBuffer.Value,
+		Members:             make([]*AdminGatheringMember, 0, session.ConnectionIDs.Size()+session.SpectatorConnectionIDs.Size()),
+		BannedPIDs:          make([]uint64, 0, len(session.BannedPIDs)),
+	}
+
+	for _, attribute := range matchmakeSession.Attributes.Slice() {
+		gathering.Attributes = append(gathering.Attributes, attribute.Value)
+	}
+
+	addMembers := func(connectionIDs *nex.MutexSlice[uint32], role SessionMemberRole) {
+		connectionIDs.Each(func(_ int, connectionID uint32) bool {
+			member := &AdminGatheringMember{
+				ConnectionID: connectionID,
+				Role:         role.String(),
+			}
+
+			connection := ah.manager.Endpoint.FindConnectionByID(connectionID)
+			if connection != nil {
+				member.PID = connection.PID().Value()
+				member.Connected = true
+			}
+
+			gathering.Members = append(gathering.Members, member)
+
+			return false
+		})
+	}
+
+	addMembers(session.ConnectionIDs, SessionMemberRoles.Participant)
+	addMembers(session.SpectatorConnectionIDs, SessionMemberRoles.Spectator)
+
+	for _, bannedPID := range session.BannedPIDs {
+		gathering.BannedPIDs = append(gathering.BannedPIDs, bannedPID.Value())
+	}
+
+	return gathering
+}
+
+func (ah *adminHandler) writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		Logger.Error(err.Error())
+	}
+}
+
+func (ah *adminHandler) writeError(w http.ResponseWriter, status int, message string) {
+	ah.writeJSON(w, status, &adminError{Error: message})
+}
+
+func (ah *adminHandler) writeNEXError(w http.ResponseWriter, errCode *nex.Error) {
+	status := http.StatusBadRequest
+	if errCode.ResultCode == nex.ResultCodes.RendezVous.SessionVoid {
+		status = http.StatusNotFound
+	}
+
+	ah.writeJSON(w, status, &adminError{Error: errCode.Message, ResultCode: errCode.ResultCode})
+}
+
+// ServeHTTP implements http.Handler
+func (ah *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if path[0] != "gatherings" {
+		ah.writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if len(path) == 1 {
+		if r.Method != http.MethodGet {
+			ah.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		gatherings := make([]*AdminGathering, 0)
+		ah.manager.EachSession(func(_ uint32, session *CommonMatchmakeSession) bool {
+			gatherings = append(gatherings, ah.newAdminGatheringImpl(session))
+			return false
+		})
+
+		ah.writeJSON(w, http.StatusOK, gatherings)
+		return
+	}
+
+	gatheringID, err := strconv.ParseUint(path[1], 10, 32)
+	if err != nil {
+		ah.writeError(w, http.StatusBadRequest, "Invalid gathering ID")
+		return
+	}
+
+	if len(path) == 2 {
+		if r.Method != http.MethodGet {
+			ah.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		var gathering *AdminGathering
+		ah.manager.EachSession(func(index uint32, session *CommonMatchmakeSession) bool {
+			if index != uint32(gatheringID) {
+				return false
+			}
+
+			gathering = ah.newAdminGatheringImpl(session)
+			return true
+		})
+
+		if gathering == nil {
+			ah.writeError(w, http.StatusNotFound, "Gathering not found")
+			return
+		}
+
+		ah.writeJSON(w, http.StatusOK, gathering)
+		return
+	}
+
+	if len(path) != 3 {
+		ah.writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		ah.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var errCode *nex.Error
+
+	switch path[2] {
+	case "close":
+		errCode = ah.manager.SetSessionOpenParticipation(uint32(gatheringID), false)
+	case "kick":
+		pid, err := strconv.ParseUint(r.URL.Query().Get("pid"), 10, 64)
+		if err != nil {
+			ah.writeError(w, http.StatusBadRequest, "Invalid PID")
+			return
+		}
+
+		ban := r.URL.Query().Get("ban") == "true"
+
+		errCode = ah.manager.AdminKickFromSession(uint32(gatheringID), types.NewPID(pid), ban)
+	case "unregister":
+		if _, ok := ah.manager.GetSession(uint32(gatheringID)); !ok {
+			errCode = nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+			break
+		}
+
+		ah.manager.RemoveSession(nil, uint32(gatheringID))
+	default:
+		ah.writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if errCode != nil {
+		ah.writeNEXError(w, errCode)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
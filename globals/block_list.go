@@ -0,0 +1,75 @@
+package common_globals
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+)
+
+// blockListCache holds the block lists fetched while going through the sessions, so every
+// block list is only requested once per search
+type blockListCache struct {
+	getUserBlockedPIDs func(pid *types.PID) []*types.PID
+	blockLists         map[uint64][]*types.PID
+}
+
+func (blc *blockListCache) get(pid *types.PID) []*types.PID {
+	blockList, ok := blc.blockLists[pid.Value()]
+	if !ok {
+		blockList = blc.getUserBlockedPIDs(pid)
+		blc.blockLists[pid.Value()] = blockList
+	}
+
+	return blockList
+}
+
+func (mm *MatchmakingManager) newBlockListCache() *blockListCache {
+	return &blockListCache{
+		getUserBlockedPIDs: mm.GetUserBlockedPIDs,
+		blockLists:         make(map[uint64][]*types.PID),
+	}
+}
+
+// isBlockedFromSessionImpl checks if the connection blocked any participant of the session, or was blocked by one.
+// Always returns false if there is no GetUserBlockedPIDs handler
+func (mm *MatchmakingManager) isBlockedFromSessionImpl(connection *nex.PRUDPConnection, session *CommonMatchmakeSession, blockLists *blockListCache) bool {
+	return mm.isPIDBlockedFromSessionImpl(connection.PID(), session, blockLists)
+}
+
+// isPIDBlockedFromSessionImpl checks if the player blocked any participant of the session, or was blocked by one.
+// The participants connected to other instances are checked too
+func (mm *MatchmakingManager) isPIDBlockedFromSessionImpl(pid *types.PID, session *CommonMatchmakeSession, blockLists *blockListCache) bool {
+	if mm.GetUserBlockedPIDs == nil {
+		return false
+	}
+
+	blockedPIDs := blockLists.get(pid)
+
+	isBlocked := func(participantPID *types.PID) bool {
+		if ContainsPID(blockedPIDs, participantPID) {
+			return true
+		}
+
+		return ContainsPID(blockLists.get(participantPID), pid)
+	}
+
+	blocked := session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		participant := mm.Endpoint.FindConnectionByID(connectionID)
+		if participant == nil {
+			return false
+		}
+
+		return isBlocked(participant.PID())
+	})
+
+	if blocked {
+		return true
+	}
+
+	for _, remoteParticipant := range mm.remoteParticipants[session.GameMatchmakeSession.Gathering.ID.Value] {
+		if isBlocked(remoteParticipant.pid) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+package common_globals
+
+import (
+	"math"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// browseCursor is the snapshot of the results of a browse, which later pages are read from
+type browseCursor struct {
+	searchCriterias    string // * Serialized search criterias, used to tell if a page belongs to the same browse
+	gatheringIDs       []uint32
+	remoteGatheringIDs map[uint32]bool // * Gathering IDs of the sessions of other instances
+	createdAt          time.Time
+}
+
+func (mm *MatchmakingManager) serializeSearchCriterias(searchCriterias []*match_making_types.MatchmakeSessionSearchCriteria) string {
+	stream := nex.NewByteStreamOut(mm.Endpoint.LibraryVersions(), mm.Endpoint.ByteStreamSettings())
+
+	for _, searchCriteria := range searchCriterias {
+		searchCriteria.WriteTo(stream)
+	}
+
+	return string(stream.Bytes())
+}
+
+// BrowseSessions returns a page of the sessions matching the search criteria, in SessionOrder.
+//
+// Browsing from offset 0 (or math.MaxUint32) takes a snapshot of the matching sessions, which the next pages
+// requested by the same connection with the same search criteria are read from. This way the pages don't skip
+// or repeat sessions when sessions are created or deleted in between. Sessions deleted since the snapshot was
+// taken are left out of the page, and new sessions only show up once the connection browses from the start again.
+//
+// Sessions published by other instances through the SessionDirectory are sorted along with the local ones.
+// They are returned as copies which must not be modified, and can't be found with GetSession.
+//
+// Returns InvalidIndex if the offset is past the end of the results
+func (mm *MatchmakingManager) BrowseSessions(connection *nex.PRUDPConnection, searchCriterias []*match_making_types.MatchmakeSessionSearchCriteria, gameSpecificChecks func(searchCriteria *match_making_types.MatchmakeSessionSearchCriteria, matchmakeSession *match_making_types.MatchmakeSession) bool, offset uint32, length uint32) ([]*CommonMatchmakeSession, *nex.Error) {
+	serializedSearchCriterias := mm.serializeSearchCriterias(searchCriterias)
+
+	if offset == math.MaxUint32 {
+		offset = 0
+	}
+
+	cursor, ok := mm.browseCursors.Get(connection.ID)
+	if offset == 0 || !ok || cursor.searchCriterias != serializedSearchCriterias || time.Since(cursor.createdAt) > mm.BrowseCursorLifetime {
+		// * Listing the remote sessions may go through the network, so do it before locking
+		remoteSessions := mm.listRemoteCommonMatchmakeSessions()
+
+		cursor = &browseCursor{
+			searchCriterias:    serializedSearchCriterias,
+			gatheringIDs:       make([]uint32, 0),
+			remoteGatheringIDs: make(map[uint32]bool, len(remoteSessions)),
+			createdAt:          time.Now(),
+		}
+
+		for _, session := range remoteSessions {
+			cursor.remoteGatheringIDs[session.GameMatchmakeSession.Gathering.ID.Value] = true
+		}
+
+		mm.sessionsMutex.RLock()
+		for _, sessionGroup := range mm.findSessionGroupsBySearchCriteriasImpl(connection, searchCriterias, gameSpecificChecks, remoteSessions) {
+			for _, session := range sessionGroup {
+				cursor.gatheringIDs = append(cursor.gatheringIDs, session.GameMatchmakeSession.Gathering.ID.Value)
+			}
+		}
+		mm.sessionsMutex.RUnlock()
+
+		mm.browseCursors.Set(connection.ID, cursor)
+	}
+
+	if int(offset) > len(cursor.gatheringIDs) {
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidIndex, "change_error")
+	}
+
+	gatheringIDs := cursor.gatheringIDs[offset:]
+	if len(gatheringIDs) > int(length) {
+		gatheringIDs = gatheringIDs[:length]
+	}
+
+	// * Remote sessions are read again, so the page holds their current state
+	var remoteSessions map[uint32]*CommonMatchmakeSession
+	for _, gatheringID := range gatheringIDs {
+		if cursor.remoteGatheringIDs[gatheringID] {
+			remoteSessions = make(map[uint32]*CommonMatchmakeSession)
+			for _, session := range mm.listRemoteCommonMatchmakeSessions() {
+				remoteSessions[session.GameMatchmakeSession.Gathering.ID.Value] = session
+			}
+
+			break
+		}
+	}
+
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	sessions := make([]*CommonMatchmakeSession, 0, len(gatheringIDs))
+	for _, gatheringID := range gatheringIDs {
+		if cursor.remoteGatheringIDs[gatheringID] {
+			if session, ok := remoteSessions[gatheringID]; ok {
+				sessions = append(sessions, session)
+			}
+		} else if session, ok := mm.sessions.Get(gatheringID); ok {
+			sessions = append(sessions, session)
+		}
+	}
+
+	return sessions, nil
+}
+
+// ClearBrowseCursor forgets the browse snapshot of a connection
+func (mm *MatchmakingManager) ClearBrowseCursor(connectionID uint32) {
+	mm.browseCursors.Delete(connectionID)
+}
@@ -0,0 +1,29 @@
+package common_globals
+
+import "github.com/PretendoNetwork/nex-go/v2/types"
+
+// LegacyGetUserFriendPIDs adapts a friend list handler which uses 32 bit PIDs to the PID based handler.
+// The PIDs given to the legacy handler are truncated, so it will not work with 64 bit PIDs
+func LegacyGetUserFriendPIDs(handler func(pid uint32) []uint32) func(pid *types.PID) []*types.PID {
+	return func(pid *types.PID) []*types.PID {
+		legacyFriendPIDs := handler(pid.LegacyValue())
+		friendPIDs := make([]*types.PID, 0, len(legacyFriendPIDs))
+
+		for _, friendPID := range legacyFriendPIDs {
+			friendPIDs = append(friendPIDs, types.NewPID(uint64(friendPID)))
+		}
+
+		return friendPIDs
+	}
+}
+
+// ContainsPID checks if the given PID is in the list
+func ContainsPID(pids []*types.PID, pid *types.PID) bool {
+	for _, other := range pids {
+		if other.Equals(pid) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,308 @@
+package common_globals
+
+import (
+	"sort"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/constants"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
+	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
+)
+
+// connectionConnectivity holds the NAT traversal reports of a connection
+type connectionConnectivity struct {
+	reported     bool
+	natMapping   constants.NATMappingProperties
+	natFiltering constants.NATFilteringProperties
+	serverRTT    uint32
+	peerRTTs     map[uint32]uint32 // * Keyed by the connection ID of the peer
+	failedPeers  map[uint32]bool
+}
+
+func newConnectionConnectivity() *connectionConnectivity {
+	return &connectionConnectivity{
+		peerRTTs:    make(map[uint32]uint32),
+		failedPeers: make(map[uint32]bool),
+	}
+}
+
+// HostCandidate is a participant which can become the new host or owner of a session
+type HostCandidate struct {
+	Connection   *nex.PRUDPConnection
+	JoinOrder    int  // * Position of the participant in the session, starting from 0
+	Reported     bool // * Whether the participant reported its NAT properties. The NAT fields are unknown if not
+	NATMapping   constants.NATMappingProperties
+	NATFiltering constants.NATFilteringProperties
+	ServerRTT    uint32 // * RTT to the server reported with ReportNATProperties
+	PeerRTT      uint32 // * Average RTT to the other participants reported with ReportNATTraversalResult, or 0 if unknown
+	FailedPeers  int    // * Number of other participants which couldn't traverse the NAT of this participant, or the other way around
+}
+
+// HostSelectionStrategy picks the new host or owner of a session out of the given candidates, in join order.
+// Returns nil if none of them is suitable
+type HostSelectionStrategy func(candidates []*HostCandidate) *HostCandidate
+
+// HostSelectionByJoinOrder picks the participant which has been in the session for the longest time
+func HostSelectionByJoinOrder(candidates []*HostCandidate) *HostCandidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	return candidates[0]
+}
+
+// HostSelectionByConnectivity picks the participant which the rest of the session can reach the best.
+//
+// Candidates are compared by the number of failed NAT traversals, then by how open their NAT is,
+// then by their RTT and finally by their join order
+func HostSelectionByConnectivity(candidates []*HostCandidate) *HostCandidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	natScore := func(candidate *HostCandidate) int {
+		score := 0
+		if candidate.NATMapping == constants.EIMNATMapping {
+			score += 2
+		}
+
+		if candidate.NATFiltering == constants.PIFNATFiltering {
+			score++
+		}
+
+		return score
+	}
+
+	rtt := func(candidate *HostCandidate) uint32 {
+		if candidate.PeerRTT != 0 {
+			return candidate.PeerRTT
+		}
+
+		return candidate.ServerRTT
+	}
+
+	sorted := make([]*HostCandidate, len(candidates))
+	copy(sorted, candidates)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].FailedPeers != sorted[j].FailedPeers {
+			return sorted[i].FailedPeers < sorted[j].FailedPeers
+		}
+
+		if natScore(sorted[i]) != natScore(sorted[j]) {
+			return natScore(sorted[i]) > natScore(sorted[j])
+		}
+
+		// * Candidates which didn't report anything go last
+		if rtt(sorted[i]) == 0 || rtt(sorted[j]) == 0 {
+			return rtt(sorted[j]) == 0 && rtt(sorted[i]) != 0
+		}
+
+		return rtt(sorted[i]) < rtt(sorted[j])
+	})
+
+	return sorted[0]
+}
+
+// ReportNATProperties records the NAT properties of a connection, used to select session hosts
+func (mm *MatchmakingManager) ReportNATProperties(connection *nex.PRUDPConnection, natMapping constants.NATMappingProperties, natFiltering constants.NATFilteringProperties, rtt uint32) {
+	mm.connectivityMutex.Lock()
+	defer mm.connectivityMutex.Unlock()
+
+	connectivity, ok := mm.connectivity[connection.ID]
+	if !ok {
+		connectivity = newConnectionConnectivity()
+		mm.connectivity[connection.ID] = connectivity
+	}
+
+	connectivity.reported = true
+	connectivity.natMapping = natMapping
+	connectivity.natFiltering = natFiltering
+	connectivity.serverRTT = rtt
+}
+
+// ReportNATTraversalResult records the result of a NAT traversal from a connection to another one, used to select session hosts
+func (mm *MatchmakingManager) ReportNATTraversalResult(connection *nex.PRUDPConnection, peerConnectionID uint32, success bool, rtt uint32) {
+	mm.connectivityMutex.Lock()
+	defer mm.connectivityMutex.Unlock()
+
+	connectivity, ok := mm.connectivity[connection.ID]
+	if !ok {
+		connectivity = newConnectionConnectivity()
+		mm.connectivity[connection.ID] = connectivity
+	}
+
+	if success {
+		connectivity.peerRTTs[peerConnectionID] = rtt
+		delete(connectivity.failedPeers, peerConnectionID)
+	} else {
+		connectivity.failedPeers[peerConnectionID] = true
+		delete(connectivity.peerRTTs, peerConnectionID)
+	}
+}
+
+// ClearConnectivity forgets the NAT traversal reports of a connection
+func (mm *MatchmakingManager) ClearConnectivity(connectionID uint32) {
+	mm.connectivityMutex.Lock()
+	defer mm.connectivityMutex.Unlock()
+
+	delete(mm.connectivity, connectionID)
+}
+
+// hostCandidatesImpl returns the participants of the session which can become its host or owner, in join order
+func (mm *MatchmakingManager) hostCandidatesImpl(session *CommonMatchmakeSession, excludedConnectionID uint32) []*HostCandidate {
+	connectionIDs := session.ConnectionIDs.Values()
+
+	mm.connectivityMutex.Lock()
+	defer mm.connectivityMutex.Unlock()
+
+	candidates := make([]*HostCandidate, 0, len(connectionIDs))
+	for joinOrder, connectionID := range connectionIDs {
+		if connectionID == excludedConnectionID {
+			continue
+		}
+
+		connection := mm.Endpoint.FindConnectionByID(connectionID)
+		if connection == nil {
+			continue
+		}
+
+		candidate := &HostCandidate{
+			Connection: connection,
+			JoinOrder:  joinOrder,
+		}
+
+		var totalRTT, measuredPeers uint32
+		for _, peerConnectionID := range connectionIDs {
+			if peerConnectionID == connectionID {
+				continue
+			}
+
+			// * Reports from either side of the traversal are taken into account
+			if connectivity, ok := mm.connectivity[connectionID]; ok {
+				if connectivity.failedPeers[peerConnectionID] {
+					candidate.FailedPeers++
+				} else if rtt, ok := connectivity.peerRTTs[peerConnectionID]; ok {
+					totalRTT += rtt
+					measuredPeers++
+				}
+			}
+
+			if connectivity, ok := mm.connectivity[peerConnectionID]; ok {
+				if connectivity.failedPeers[connectionID] {
+					candidate.FailedPeers++
+				} else if rtt, ok := connectivity.peerRTTs[connectionID]; ok {
+					totalRTT += rtt
+					measuredPeers++
+				}
+			}
+		}
+
+		if measuredPeers != 0 {
+			candidate.PeerRTT = totalRTT / measuredPeers
+		}
+
+		if connectivity, ok := mm.connectivity[connectionID]; ok && connectivity.reported {
+			candidate.Reported = true
+			candidate.NATMapping = connectivity.natMapping
+			candidate.NATFiltering = connectivity.natFiltering
+			candidate.ServerRTT = connectivity.serverRTT
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+// selectHostImpl picks the participant of the session which should become its new host or owner using HostSelection.
+// Returns nil if there is no suitable participant
+func (mm *MatchmakingManager) selectHostImpl(session *CommonMatchmakeSession, excludedConnectionID uint32) *nex.PRUDPConnection {
+	strategy := mm.HostSelection
+	if strategy == nil {
+		strategy = HostSelectionByJoinOrder
+	}
+
+	candidate := strategy(mm.hostCandidatesImpl(session, excludedConnectionID))
+	if candidate == nil {
+		return nil
+	}
+
+	return candidate.Connection
+}
+
+// scheduleHostMigration migrates the host of the session after HostVanishedTimeout,
+// unless a participant updates the host or the vanished host comes back before that
+func (mm *MatchmakingManager) scheduleHostMigration(gatheringID uint32, vanishedHostPID *types.PID) {
+	if mm.HostVanishedTimeout == 0 {
+		return
+	}
+
+	time.AfterFunc(mm.HostVanishedTimeout, func() {
+		mm.sessionsMutex.Lock()
+		defer mm.sessionsMutex.Unlock()
+
+		session, ok := mm.sessions.Get(gatheringID)
+		if !ok {
+			return
+		}
+
+		if !session.GameMatchmakeSession.Gathering.HostPID.Equals(vanishedHostPID) || mm.findParticipantImpl(session, vanishedHostPID) != nil {
+			return
+		}
+
+		newHost := mm.selectHostImpl(session, 0)
+		if newHost == nil {
+			return
+		}
+
+		if mm.SessionManagementDebugLog {
+			globals.Logger.Infof("GID %d: Host PID %d vanished, migrating HOST to PID %d", gatheringID, vanishedHostPID.Value(), newHost.PID().Value())
+		}
+
+		session.GameMatchmakeSession.Gathering.HostPID = newHost.PID().Copy().(*types.PID)
+
+		mm.emitSessionEventImpl(SessionEventTypes.HostChanged, session, newHost, SessionEventReasons.Migration)
+
+		category := notifications.NotificationCategories.HostChanged
+		subtype := notifications.NotificationSubTypes.HostChanged.None
+
+		oEvent := NewNotificationEvent()
+		oEvent.PIDSource = newHost.PID()
+		oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
+		oEvent.Param1 = types.NewPrimitiveU64(uint64(gatheringID))
+		oEvent.Param2 = types.NewPrimitiveU64(0) // TODO - Research what this means
+
+		logNotificationDeliveryFailures(mm.sendNotificationEventToSessionImpl(session, oEvent))
+	})
+}
+
+// VerifyHostUpdate checks if the connection is allowed to become the host of the session.
+// Spectators are never allowed. Otherwise, it always succeeds unless VerifyHostUpdates is set, in which case the
+// host can only be taken over by the owner, or by anyone once the current host has left the session.
+// Returns a NEX error code if it isn't allowed
+func (mm *MatchmakingManager) VerifyHostUpdate(connection *nex.PRUDPConnection, session *CommonMatchmakeSession) *nex.Error {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	if session.SpectatorConnectionIDs.Has(connection.ID) {
+		return nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	if !mm.VerifyHostUpdates {
+		return nil
+	}
+
+	hostPID := session.GameMatchmakeSession.Gathering.HostPID
+	if hostPID.Equals(connection.PID()) || session.GameMatchmakeSession.Gathering.OwnerPID.Equals(connection.PID()) {
+		return nil
+	}
+
+	if mm.findParticipantImpl(session, hostPID) != nil {
+		return nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	return nil
+}
@@ -1,7 +1,12 @@
 package common_globals
 
 import (
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
 	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
 )
 
@@ -9,9 +14,77 @@ type CommonMatchmakeSession struct {
 	GameMatchmakeSession   *match_making_types.MatchmakeSession // * Used by the game, contains the current state of the MatchmakeSession
 	SearchMatchmakeSession *match_making_types.MatchmakeSession // * Used by the server when searching for matches, contains the state of the MatchmakeSession during the search process for easy compares
 	ConnectionIDs          *nex.MutexSlice[uint32]              // * Players in the room, referenced by their connection IDs. This is used instead of the PID in order to ensure we're talking to the correct client (in case of e.g. multiple logins)
+	SpectatorConnectionIDs *nex.MutexSlice[uint32]              // * Members watching the room without taking part in it. They receive the notifications of the session, but don't use slots and can't become its owner or host
+	UserPassword           string                               // * Kept out of GameMatchmakeSession so it's never sent to other clients
+	SystemPassword         string                               // * Set by GenerateMatchmakeSessionSystemPassword
+	BannedPIDs             []*types.PID                         // * Players kicked with a ban, which can't find or join the session again
+	lastActivity           atomic.Int64                         // * Unix time in nanoseconds of the last game state update
+	reservedSlots          map[uint32]int                       // * Extra slots held by each participant for their guests, keyed by connection ID
+	reservedSlotsMutex     sync.Mutex
+}
+
+// MarkActivity records that the game state of the session was just updated
+func (cms *CommonMatchmakeSession) MarkActivity() {
+	cms.lastActivity.Store(time.Now().UnixNano())
+}
+
+// LastActivity returns the last time the game state of the session was updated
+func (cms *CommonMatchmakeSession) LastActivity() time.Time {
+	return time.Unix(0, cms.lastActivity.Load())
+}
+
+// ReservedSlots returns the number of slots held by the participants for their guest players and co-located friends
+func (cms *CommonMatchmakeSession) ReservedSlots() int {
+	cms.reservedSlotsMutex.Lock()
+	defer cms.reservedSlotsMutex.Unlock()
+
+	reservedSlots := 0
+	for _, slots := range cms.reservedSlots {
+		reservedSlots += slots
+	}
+
+	return reservedSlots
+}
+
+// OccupiedSlots returns the number of slots used by the participants of this server and their guests
+func (cms *CommonMatchmakeSession) OccupiedSlots() int {
+	return cms.ConnectionIDs.Size() + cms.ReservedSlots()
 }
 
+// PartySize returns the number of slots used by a participant, including the slots reserved for its guests.
+// Returns 0 if the connection isn't a participant
+func (cms *CommonMatchmakeSession) PartySize(connectionID uint32) int {
+	if !cms.ConnectionIDs.Has(connectionID) {
+		return 0
+	}
 
-var GetUserFriendPIDsHandler func(pid uint32) []uint32
-var CurrentGatheringID = nex.NewCounter[uint32](0)
-var CurrentMatchmakingCallID = nex.NewCounter[uint32](0)
+	cms.reservedSlotsMutex.Lock()
+	defer cms.reservedSlotsMutex.Unlock()
+
+	return 1 + cms.reservedSlots[connectionID]
+}
+
+// reserveSlots holds the given number of extra slots for the guests of a participant, replacing any previous reservation
+func (cms *CommonMatchmakeSession) reserveSlots(connectionID uint32, slots int) {
+	cms.reservedSlotsMutex.Lock()
+	defer cms.reservedSlotsMutex.Unlock()
+
+	if slots <= 0 {
+		delete(cms.reservedSlots, connectionID)
+		return
+	}
+
+	if cms.reservedSlots == nil {
+		cms.reservedSlots = make(map[uint32]int)
+	}
+
+	cms.reservedSlots[connectionID] = slots
+}
+
+// releaseSlots frees the slots held for the guests of a participant
+func (cms *CommonMatchmakeSession) releaseSlots(connectionID uint32) {
+	cms.reservedSlotsMutex.Lock()
+	defer cms.reservedSlotsMutex.Unlock()
+
+	delete(cms.reservedSlots, connectionID)
+}
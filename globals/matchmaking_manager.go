@@ -0,0 +1,92 @@
+package common_globals
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// MatchmakingManager holds the matchmaking state of a single endpoint, such as its sessions,
+// counters and event handlers. Several managers can live in the same process without sharing anything.
+//
+// The match-making, match-making-ext and matchmake-extension common protocols of an endpoint must
+// all be given the same MatchmakingManager
+type MatchmakingManager struct {
+	Endpoint                          *nex.PRUDPEndPoint
+	sessions                          SessionStore
+	sessionsMutex                     *sync.RWMutex
+	CurrentGatheringID                *nex.Counter[uint32]
+	CurrentMatchmakingCallID          *nex.Counter[uint32]
+	GetUserFriendPIDs                 func(pid *types.PID) []*types.PID
+	GetUserBlockedPIDs                func(pid *types.PID) []*types.PID
+	SessionManagementDebugLog         bool
+	SessionRanking                    *SessionRankingConfig // * Ranks the candidates of automatic matchmakes. The first candidate found is used if nil
+	SessionOrder                      SessionComparator     // * Order of the found sessions. Sorted by gathering ID if nil
+	BrowseCursorLifetime              time.Duration
+	browseCursors                     *nex.MutexMap[uint32, *browseCursor]
+	participationPolicies             *nex.MutexMap[uint32, ParticipationPolicy]
+	participationPoliciesWithArgument *nex.MutexMap[participationPolicyKey, ParticipationPolicy]
+	InvitationLifetime                time.Duration
+	InvitationNotifications           *InvitationNotificationConfig // * Notification types of the invitation notifications. They aren't sent if nil
+	invitations                       map[uint32][]*sessionInvitation
+	invitationsMutex                  *sync.Mutex
+	HostSelection                     HostSelectionStrategy // * Picks the new owner, and the new host once it vanishes. Uses the join order if nil
+	HostVanishedTimeout               time.Duration         // * Time to wait for a new host after the host leaves the session, before the server picks one. Disabled if 0
+	VerifyHostUpdates                 bool
+	connectivity                      map[uint32]*connectionConnectivity
+	connectivityMutex                 *sync.Mutex
+	MatchmakingQueue                  *MatchmakingQueueConfig // * Batches AutoMatchmake_Postpone searches. Disabled if nil
+	matchmakeBatches                  []*matchmakeBatch
+	matchmakeBatchesMutex             *sync.Mutex
+	onSessionCreatedHandlers          []func(gid uint32)
+	onSessionDeletedHandlers          []func(gid uint32)
+	onPlayerJoinSessionHandlers       []func(gid uint32, cid uint32)
+	onPlayerLeaveSessionHandlers      []func(gid uint32, cid uint32, gracefully bool)
+	sessionJoinChecks                 []func(pid *types.PID, matchmakeSession *match_making_types.MatchmakeSession) *nex.Error
+	sessionEventSubscribers           *nex.MutexSlice[*sessionEventSubscriber]
+	SessionEventQueueSize             int // * Maximum number of events queued for a subscriber. A subscriber which falls further behind is unsubscribed
+	filterFoundCandidateSessions      []func(sessions []uint32, connection *nex.PRUDPConnection, searchMatchmakeSession *match_making_types.MatchmakeSession) []uint32
+	pendingMembers                    map[uint32][]*pendingMember // * Restored participants and spectators which haven't reconnected yet
+	pendingMemberCount                atomic.Int32
+	sessionDirectory                  SessionDirectory
+	instanceID                        string
+	remoteParticipants                map[uint32][]*remoteParticipant          // * Participants connected to other instances, keyed by gathering ID
+	remoteMemberships                 *nex.MutexMap[uint32, *DirectorySession] // * Sessions of other instances joined by our connections, keyed by connection ID
+	metrics                           *Metrics                                 // * Receives the metrics of the matchmaking protocols handlers, and the reports of StartMetricsReporting
+}
+
+// NewMatchmakingManager returns a new MatchmakingManager for the given endpoint, using an in-memory session store
+func NewMatchmakingManager(endpoint *nex.PRUDPEndPoint) *MatchmakingManager {
+	mm := &MatchmakingManager{
+		Endpoint:                          endpoint,
+		sessions:                          NewMemorySessionStore(),
+		sessionsMutex:                     &sync.RWMutex{},
+		CurrentGatheringID:                nex.NewCounter[uint32](0),
+		CurrentMatchmakingCallID:          nex.NewCounter[uint32](0),
+		pendingMembers:                    make(map[uint32][]*pendingMember),
+		BrowseCursorLifetime:              time.Minute,
+		browseCursors:                     nex.NewMutexMap[uint32, *browseCursor](),
+		participationPolicies:             nex.NewMutexMap[uint32, ParticipationPolicy](),
+		participationPoliciesWithArgument: nex.NewMutexMap[participationPolicyKey, ParticipationPolicy](),
+		InvitationLifetime:                5 * time.Minute,
+		invitations:                       make(map[uint32][]*sessionInvitation),
+		invitationsMutex:                  &sync.Mutex{},
+		connectivity:                      make(map[uint32]*connectionConnectivity),
+		connectivityMutex:                 &sync.Mutex{},
+		matchmakeBatchesMutex:             &sync.Mutex{},
+		sessionEventSubscribers:           nex.NewMutexSlice[*sessionEventSubscriber](),
+		SessionEventQueueSize:             1024,
+		remoteParticipants:                make(map[uint32][]*remoteParticipant),
+		remoteMemberships:                 nex.NewMutexMap[uint32, *DirectorySession](),
+		metrics:                           NewMetrics(),
+	}
+
+	// * Policy used by games such as Mario Kart 7 for friends-only sessions
+	mm.RegisterParticipationPolicy(98, ParticipationPolicyFriendsOfOwner)
+
+	return mm
+}
@@ -0,0 +1,215 @@
+package common_globals
+
+import (
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// MatchmakingQueueConfig enables the queue mode of AutoMatchmake_Postpone.
+//
+// When no existing session can be joined, the search is held for Window so that other searchers
+// can be batched with it. A session is then created for the whole batch at once, hosted by the first searcher.
+// Batches with less than MinimumBatchSize searchers fall back to creating or joining a session as usual,
+// with the whole batch joining the same session
+type MatchmakingQueueConfig struct {
+	Window           time.Duration // * Time a search is held for other searchers. The batch is created earlier if it gets full
+	MinimumBatchSize int           // * Searchers needed to create a session out of a batch. Values lower than 2 are treated as 2
+}
+
+// matchmakeBatchMember is a searcher waiting in a matchmake batch
+type matchmakeBatchMember struct {
+	connection *nex.PRUDPConnection
+	message    string
+}
+
+// matchmakeBatch is a group of compatible searchers waiting for a session to be created for them
+type matchmakeBatch struct {
+	session                     *CommonMatchmakeSession // * Provisional session, never registered. Used to check if a searcher can join the batch
+	searchMatchmakeSession      *match_making_types.MatchmakeSession
+	dirtySearchMatchmakeSession *match_making_types.MatchmakeSession // * Search of the first searcher, before being cleaned up
+	members                     []*matchmakeBatchMember
+	timer                       *time.Timer
+	flushed                     bool
+	done                        chan struct{}
+	results                     map[uint32]*CommonMatchmakeSession // * Keyed by connection ID. Only written before done is closed
+}
+
+// pruneMatchmakeBatchImpl drops the searchers which disconnected while waiting from the batch.
+// Requires matchmakeBatchesMutex to be locked
+func (mm *MatchmakingManager) pruneMatchmakeBatchImpl(batch *matchmakeBatch) {
+	members := batch.members[:0]
+	for _, member := range batch.members {
+		if mm.Endpoint.FindConnectionByID(member.connection.ID) != nil {
+			members = append(members, member)
+		} else {
+			batch.session.ConnectionIDs.DeleteAll(member.connection.ID)
+		}
+	}
+
+	batch.members = members
+}
+
+// findMatchmakeBatchImpl returns a waiting batch which the connection can join, or nil if there is none.
+// Requires matchmakeBatchesMutex to be locked
+func (mm *MatchmakingManager) findMatchmakeBatchImpl(connection *nex.PRUDPConnection, searchMatchmakeSession *match_making_types.MatchmakeSession) *matchmakeBatch {
+	var friendPIDs []*types.PID
+	blockLists := mm.newBlockListCache()
+
+	for _, batch := range mm.matchmakeBatches {
+		if batch.flushed || !batch.searchMatchmakeSession.Equals(searchMatchmakeSession) {
+			continue
+		}
+
+		mm.pruneMatchmakeBatchImpl(batch)
+
+		if len(batch.members) >= int(batch.session.GameMatchmakeSession.Gathering.MaximumParticipants.Value) {
+			continue
+		}
+
+		if !mm.canParticipateImpl(connection, batch.session, &friendPIDs) {
+			continue
+		}
+
+		if mm.isBlockedFromSessionImpl(connection, batch.session, blockLists) {
+			continue
+		}
+
+		return batch
+	}
+
+	return nil
+}
+
+// flushMatchmakeBatch closes the batch and adds the searchers which are still connected to the same session.
+// The session is created for the batch if it has enough searchers, otherwise an existing session with room for
+// the whole batch is joined if there is one. Does nothing if the batch was already flushed
+func (mm *MatchmakingManager) flushMatchmakeBatch(batch *matchmakeBatch) {
+	mm.matchmakeBatchesMutex.Lock()
+	if batch.flushed {
+		mm.matchmakeBatchesMutex.Unlock()
+		return
+	}
+
+	mm.pruneMatchmakeBatchImpl(batch)
+
+	batch.flushed = true
+	for i, waitingBatch := range mm.matchmakeBatches {
+		if waitingBatch == batch {
+			mm.matchmakeBatches = append(mm.matchmakeBatches[:i], mm.matchmakeBatches[i+1:]...)
+			break
+		}
+	}
+
+	// * Nothing joins the batch once it is flushed
+	members := batch.members
+	mm.matchmakeBatchesMutex.Unlock()
+
+	defer close(batch.done)
+
+	if len(members) == 0 {
+		return
+	}
+
+	minimumBatchSize := mm.MatchmakingQueue.MinimumBatchSize
+	if minimumBatchSize < 2 {
+		minimumBatchSize = 2
+	}
+
+	host := members[0].connection
+
+	var session *CommonMatchmakeSession
+	if len(members) < minimumBatchSize {
+		// * Sessions may have been created while waiting
+		gatheringID := mm.FindSessionByMatchmakeSession(host, batch.searchMatchmakeSession, batch.dirtySearchMatchmakeSession, uint16(len(members)))
+		if gatheringID != 0 {
+			session, _ = mm.GetSession(gatheringID)
+		}
+	}
+
+	if session == nil {
+		var errCode *nex.Error
+		session, errCode = mm.CreateSessionByMatchmakeSession(batch.session.GameMatchmakeSession, batch.searchMatchmakeSession, host.PID())
+		if errCode != nil {
+			Logger.Error(errCode.Error())
+			return
+		}
+
+		if mm.SessionManagementDebugLog {
+			globals.Logger.Infof("GID %d: Created for a batch of %d searchers", session.GameMatchmakeSession.Gathering.ID.Value, len(members))
+		}
+	}
+
+	for _, member := range members {
+		errCode := mm.AddPlayersToSession(session, []uint32{member.connection.ID}, member.connection, member.message)
+		if errCode != nil {
+			Logger.Error(errCode.Error())
+			continue
+		}
+
+		batch.results[member.connection.ID] = session
+	}
+}
+
+// QueueAutoMatchmake holds an automatic matchmake search until it is batched with other searchers, following MatchmakingQueue.
+// The call blocks until the batch is given a session, which is at most the queue window.
+//
+// Returns the session the connection was added to, or nil if it couldn't be added to the session of the batch.
+// In that case the caller should fall back to creating or joining a session as usual
+func (mm *MatchmakingManager) QueueAutoMatchmake(connection *nex.PRUDPConnection, matchmakeSession *match_making_types.MatchmakeSession, searchMatchmakeSession *match_making_types.MatchmakeSession, dirtySearchMatchmakeSession *match_making_types.MatchmakeSession, message string) *CommonMatchmakeSession {
+	if mm.MatchmakingQueue == nil {
+		return nil
+	}
+
+	member := &matchmakeBatchMember{
+		connection: connection,
+		message:    message,
+	}
+
+	mm.matchmakeBatchesMutex.Lock()
+
+	batch := mm.findMatchmakeBatchImpl(connection, searchMatchmakeSession)
+	if batch == nil {
+		// * The session of the caller is left untouched, as it may still be used if the batch falls through
+		provisionalSession := &CommonMatchmakeSession{
+			GameMatchmakeSession:   matchmakeSession.Copy().(*match_making_types.MatchmakeSession),
+			ConnectionIDs:          nex.NewMutexSlice[uint32](),
+			SpectatorConnectionIDs: nex.NewMutexSlice[uint32](),
+		}
+
+		provisionalSession.GameMatchmakeSession.Gathering.OwnerPID = connection.PID()
+		provisionalSession.GameMatchmakeSession.Gathering.HostPID = connection.PID()
+
+		batch = &matchmakeBatch{
+			session:                     provisionalSession,
+			searchMatchmakeSession:      searchMatchmakeSession,
+			dirtySearchMatchmakeSession: dirtySearchMatchmakeSession,
+			done:                        make(chan struct{}),
+			results:                     make(map[uint32]*CommonMatchmakeSession),
+		}
+
+		mm.matchmakeBatches = append(mm.matchmakeBatches, batch)
+		batch.timer = time.AfterFunc(mm.MatchmakingQueue.Window, func() {
+			mm.flushMatchmakeBatch(batch)
+		})
+	}
+
+	batch.members = append(batch.members, member)
+	batch.session.ConnectionIDs.Add(connection.ID)
+
+	full := len(batch.members) >= int(batch.session.GameMatchmakeSession.Gathering.MaximumParticipants.Value)
+
+	mm.matchmakeBatchesMutex.Unlock()
+
+	if full {
+		batch.timer.Stop()
+		mm.flushMatchmakeBatch(batch)
+	}
+
+	<-batch.done
+
+	return batch.results[connection.ID]
+}
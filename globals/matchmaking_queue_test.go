@@ -0,0 +1,199 @@
+package common_globals
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// newTestEndpointConnection returns a connection registered on the endpoint of the manager, as if its client had connected.
+// Only the endpoint can set the endpoint of a connection, so it is set through reflection.
+// The packets sent to it go nowhere, as the server doesn't listen on any socket
+func newTestEndpointConnection(mm *MatchmakingManager, connectionID uint32, pid uint64) *nex.PRUDPConnection {
+	connection := newTestConnection(mm, connectionID, pid)
+	connection.StreamSettings = mm.Endpoint.DefaultStreamSettings.Copy()
+
+	endpoint := reflect.ValueOf(connection).Elem().FieldByName("endpoint")
+	reflect.NewAt(endpoint.Type(), unsafe.Pointer(endpoint.UnsafeAddr())).Elem().Set(reflect.ValueOf(mm.Endpoint))
+
+	mm.Endpoint.Connections.Set(fmt.Sprintf("test-%d", connectionID), connection)
+
+	return connection
+}
+
+func newTestQueueManager(window time.Duration, minimumBatchSize int) *MatchmakingManager {
+	mm := newTestMatchmakingManager()
+	mm.MatchmakingQueue = &MatchmakingQueueConfig{
+		Window:           window,
+		MinimumBatchSize: minimumBatchSize,
+	}
+
+	return mm
+}
+
+// queueSearchers queues every connection at once, and returns the session each of them got
+func queueSearchers(mm *MatchmakingManager, matchmakeSession *match_making_types.MatchmakeSession, connections ...*nex.PRUDPConnection) []*CommonMatchmakeSession {
+	searchMatchmakeSession := matchmakeSession.Copy().(*match_making_types.MatchmakeSession)
+	sessions := make([]*CommonMatchmakeSession, len(connections))
+
+	var wg sync.WaitGroup
+	for i, connection := range connections {
+		wg.Add(1)
+		go func(i int, connection *nex.PRUDPConnection) {
+			defer wg.Done()
+			sessions[i] = mm.QueueAutoMatchmake(connection, matchmakeSession, searchMatchmakeSession, matchmakeSession.Copy().(*match_making_types.MatchmakeSession), "")
+		}(i, connection)
+
+		// * Keeps the order of the batch, so the first connection is the host
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	wg.Wait()
+
+	return sessions
+}
+
+func TestMatchmakingQueueFullBatch(t *testing.T) {
+	mm := newTestQueueManager(time.Minute, 2)
+	first := newTestEndpointConnection(mm, 1, 100)
+	second := newTestEndpointConnection(mm, 2, 200)
+
+	matchmakeSession := newTestMatchmakeSession(2)
+	sessions := queueSearchers(mm, matchmakeSession, first, second)
+
+	// * The batch is flushed as soon as it is full, long before the window ends
+	if sessions[0] == nil || sessions[0] != sessions[1] {
+		t.Fatalf("Both searchers should get the same session, got %v and %v", sessions[0], sessions[1])
+	}
+
+	if !sessions[0].GameMatchmakeSession.Gathering.OwnerPID.Equals(first.PID()) {
+		t.Errorf("The session is owned by PID %d, expected the first searcher", sessions[0].GameMatchmakeSession.Gathering.OwnerPID.Value())
+	}
+
+	if sessions[0].ConnectionIDs.Size() != 2 {
+		t.Errorf("The session has %d participants, expected 2", sessions[0].ConnectionIDs.Size())
+	}
+
+	if matchmakeSession.Gathering.OwnerPID.Value() != 0 || matchmakeSession.Gathering.HostPID.Value() != 0 {
+		t.Error("The session of the caller was modified")
+	}
+}
+
+func TestMatchmakingQueueShortBatchSharesSession(t *testing.T) {
+	mm := newTestQueueManager(50*time.Millisecond, 3)
+	first := newTestEndpointConnection(mm, 1, 100)
+	second := newTestEndpointConnection(mm, 2, 200)
+
+	sessions := queueSearchers(mm, newTestMatchmakeSession(4), first, second)
+
+	if sessions[0] == nil || sessions[0] != sessions[1] {
+		t.Fatalf("A short batch should still share a session, got %v and %v", sessions[0], sessions[1])
+	}
+
+	if !sessions[0].GameMatchmakeSession.Gathering.OwnerPID.Equals(first.PID()) {
+		t.Errorf("The session is owned by PID %d, expected the first searcher", sessions[0].GameMatchmakeSession.Gathering.OwnerPID.Value())
+	}
+
+	if mm.sessions.Len() != 1 {
+		t.Errorf("%d sessions were created, expected 1", mm.sessions.Len())
+	}
+}
+
+func TestMatchmakingQueueShortBatchJoinsExistingSession(t *testing.T) {
+	mm := newTestQueueManager(100*time.Millisecond, 3)
+	host := newTestEndpointConnection(mm, 1, 100)
+	searcher := newTestEndpointConnection(mm, 2, 200)
+
+	matchmakeSession := newTestMatchmakeSession(4)
+
+	var sessions []*CommonMatchmakeSession
+	done := make(chan struct{})
+	go func() {
+		sessions = queueSearchers(mm, matchmakeSession, searcher)
+		close(done)
+	}()
+
+	// * Created by someone else while the searcher waits
+	time.Sleep(20 * time.Millisecond)
+
+	existingSession, errCode := mm.CreateSessionByMatchmakeSession(matchmakeSession.Copy().(*match_making_types.MatchmakeSession), matchmakeSession.Copy().(*match_making_types.MatchmakeSession), host.PID())
+	if errCode != nil {
+		t.Fatal(errCode)
+	}
+
+	existingSession.ConnectionIDs.Add(host.ID)
+
+	<-done
+
+	if sessions[0] != existingSession {
+		t.Fatalf("The searcher should join the existing session, got %v", sessions[0])
+	}
+
+	if existingSession.ConnectionIDs.Size() != 2 {
+		t.Errorf("The session has %d participants, expected 2", existingSession.ConnectionIDs.Size())
+	}
+}
+
+func TestMatchmakingQueueDropsDisconnectedSearchers(t *testing.T) {
+	mm := newTestQueueManager(50*time.Millisecond, 2)
+	first := newTestEndpointConnection(mm, 1, 100)
+	second := newTestEndpointConnection(mm, 2, 200)
+	third := newTestEndpointConnection(mm, 3, 300)
+
+	var sessions []*CommonMatchmakeSession
+	done := make(chan struct{})
+	go func() {
+		sessions = queueSearchers(mm, newTestMatchmakeSession(4), first, second, third)
+		close(done)
+	}()
+
+	time.Sleep(40 * time.Millisecond)
+	mm.Endpoint.Connections.Delete("test-2")
+
+	<-done
+
+	if sessions[1] != nil {
+		t.Error("The disconnected searcher shouldn't get a session")
+	}
+
+	if sessions[0] == nil || sessions[0] != sessions[2] {
+		t.Fatalf("The remaining searchers should share a session, got %v and %v", sessions[0], sessions[2])
+	}
+
+	if sessions[0].ConnectionIDs.Has(second.ID) || sessions[0].ConnectionIDs.Size() != 2 {
+		t.Errorf("The session has participants %v, expected only the connected searchers", sessions[0].ConnectionIDs.Values())
+	}
+}
+
+func TestMatchmakingQueueSeparatesSearches(t *testing.T) {
+	mm := newTestQueueManager(50*time.Millisecond, 2)
+	first := newTestEndpointConnection(mm, 1, 100)
+	second := newTestEndpointConnection(mm, 2, 200)
+
+	otherGameMode := newTestMatchmakeSession(4)
+	otherGameMode.GameMode = types.NewPrimitiveU32(1)
+
+	var sessions [2][]*CommonMatchmakeSession
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sessions[0] = queueSearchers(mm, newTestMatchmakeSession(4), first)
+	}()
+	go func() {
+		defer wg.Done()
+		sessions[1] = queueSearchers(mm, otherGameMode, second)
+	}()
+	wg.Wait()
+
+	if sessions[0][0] == nil || sessions[1][0] == nil || sessions[0][0] == sessions[1][0] {
+		t.Errorf("Different searches should get different sessions, got %v and %v", sessions[0][0], sessions[1][0])
+	}
+}
@@ -1,96 +1,99 @@
 package common_globals
 
 import (
-	"sync"
 	"crypto/rand"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/PretendoNetwork/nex-go/v2"
-	"github.com/PretendoNetwork/nex-go/v2/constants"
 	"github.com/PretendoNetwork/nex-go/v2/types"
 	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
 	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
 	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
 	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
-	notifications_types "github.com/PretendoNetwork/nex-protocols-go/v2/notifications/types"
-	"golang.org/x/exp/slices"
 )
 
-var sessions map[uint32]*CommonMatchmakeSession
-var sessionsMutex = sync.RWMutex{}
+// SetSessionStore replaces the store used to hold the sessions. The sessions in the previous store are not migrated
+func (mm *MatchmakingManager) SetSessionStore(store SessionStore) {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
 
-var onSessionCreatedHandlers []func(gid uint32)
-var onSessionDeletedHandlers []func(gid uint32)
-var onPlayerJoinSessionHandlers []func(gid uint32, cid uint32)
-var onPlayerLeaveSessionHandlers []func(gid uint32, cid uint32, gracefully bool)
-
-var filterFoundCandidateSessions []func(sessions []uint32, connection *nex.PRUDPConnection, searchMatchmakeSession *match_making_types.MatchmakeSession) []uint32
-
-var SessionManagementDebugLog = false
-
-func MakeSessions() {
-	sessions = make(map[uint32]*CommonMatchmakeSession)
+	mm.sessions = store
 }
 
 // GetSession returns a session using the gathering ID.
-func GetSession(gatheringID uint32) (*CommonMatchmakeSession, bool) {
-	sessionsMutex.RLock()
-	defer sessionsMutex.RUnlock()
+func (mm *MatchmakingManager) GetSession(gatheringID uint32) (*CommonMatchmakeSession, bool) {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
 
-	ret, ok := sessions[gatheringID]
-	return ret, ok
+	return mm.sessions.Get(gatheringID)
 }
 
 // EachSession runs a callback for every session until it returns true
-func EachSession(callback func(index uint32, value *CommonMatchmakeSession) bool) bool {
-	sessionsMutex.RLock()
-	defer sessionsMutex.RUnlock()
+func (mm *MatchmakingManager) EachSession(callback func(index uint32, value *CommonMatchmakeSession) bool) bool {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
 
-	for i, value := range sessions {
-		if callback(i, value) {
-			return true
-		}
-	}
-
-	return false
+	return mm.sessions.Each(callback)
 }
 
 // OnSessionCreated sets a callback that will run just after a session is created
-func OnSessionCreated(handler func(gid uint32)) {
-	onSessionCreatedHandlers = append(onSessionCreatedHandlers, handler)
+func (mm *MatchmakingManager) OnSessionCreated(handler func(gid uint32)) {
+	mm.onSessionCreatedHandlers = append(mm.onSessionCreatedHandlers, handler)
 }
 
 // OnSessionDeleted sets a callback that will run just before a session is deleted
-func OnSessionDeleted(handler func(gid uint32)) {
-	onSessionDeletedHandlers = append(onSessionDeletedHandlers, handler)
+func (mm *MatchmakingManager) OnSessionDeleted(handler func(gid uint32)) {
+	mm.onSessionDeletedHandlers = append(mm.onSessionDeletedHandlers, handler)
 }
 
 // OnPlayerJoinSession sets a callback that will run just after a connection joins a session
-func OnPlayerJoinSession(handler func(gid uint32, cid uint32)) {
-	onPlayerJoinSessionHandlers = append(onPlayerJoinSessionHandlers, handler)
+func (mm *MatchmakingManager) OnPlayerJoinSession(handler func(gid uint32, cid uint32)) {
+	mm.onPlayerJoinSessionHandlers = append(mm.onPlayerJoinSessionHandlers, handler)
 }
 
 // OnPlayerLeaveSession sets a callback that will run just before a connection leaves a session
-func OnPlayerLeaveSession(handler func(gid uint32, cid uint32, gracefully bool)) {
-	onPlayerLeaveSessionHandlers = append(onPlayerLeaveSessionHandlers, handler)
+func (mm *MatchmakingManager) OnPlayerLeaveSession(handler func(gid uint32, cid uint32, gracefully bool)) {
+	mm.onPlayerLeaveSessionHandlers = append(mm.onPlayerLeaveSessionHandlers, handler)
+}
+
+// AddSessionJoinCheck adds a check run by VerifySessionJoin on a copy of the session, before the session mutex is locked,
+// so it may take a while, such as when querying a database.
+// The check returns a NEX error code if the player can't join the session, including players of other instances
+func (mm *MatchmakingManager) AddSessionJoinCheck(check func(pid *types.PID, matchmakeSession *match_making_types.MatchmakeSession) *nex.Error) {
+	mm.sessionJoinChecks = append(mm.sessionJoinChecks, check)
 }
 
 // FilterFoundCandidateSessions sets a callback that filters or reorders the found sessions, with the session mutex already RLocked
-func FilterFoundCandidateSessions(handler func(sessions []uint32, connection *nex.PRUDPConnection, searchMatchmakeSession *match_making_types.MatchmakeSession) []uint32) {
-	filterFoundCandidateSessions = append(filterFoundCandidateSessions, handler)
+func (mm *MatchmakingManager) FilterFoundCandidateSessions(handler func(sessions []uint32, connection *nex.PRUDPConnection, searchMatchmakeSession *match_making_types.MatchmakeSession) []uint32) {
+	mm.filterFoundCandidateSessions = append(mm.filterFoundCandidateSessions, handler)
 }
 
 // GetAvailableGatheringID returns a gathering ID which doesn't belong to any session
 // Returns 0 if no IDs are available (math.MaxUint32 has been reached)
-func GetAvailableGatheringID() uint32 {
-	return CurrentGatheringID.Next()
+func (mm *MatchmakingManager) GetAvailableGatheringID() uint32 {
+	return mm.CurrentGatheringID.Next()
 }
 
-func findOtherConnectionIDImpl(excludedConnectionID uint32, gatheringID uint32) uint32 {
+// AllocateGatheringID returns a new gathering ID for a gathering which isn't held by the manager, such as a community,
+// so that it never collides with the ones of the sessions.
+// Gatherings stored elsewhere must be accounted for by starting CurrentGatheringID past their IDs
+func (mm *MatchmakingManager) AllocateGatheringID() uint32 {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	gatheringID := mm.GetAvailableGatheringID()
+	if gatheringID == 0 {
+		gatheringID = mm.GetAvailableGatheringID() // * Skip to index 1
+	}
+
+	return gatheringID
+}
+
+func (mm *MatchmakingManager) findOtherConnectionIDImpl(excludedConnectionID uint32, gatheringID uint32) uint32 {
 	var otherConnectionID uint32 = 0
-	if session, ok := sessions[gatheringID]; ok {
+	if session, ok := mm.sessions.Get(gatheringID); ok {
 		session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
 			if connectionID != excludedConnectionID {
 				otherConnectionID = connectionID
@@ -106,109 +109,86 @@ func findOtherConnectionIDImpl(excludedConnectionID uint32, gatheringID uint32)
 
 // FindOtherConnectionID searches a connection ID on the session that isn't the given one
 // Returns 0 if no connection ID could be found
-func FindOtherConnectionID(excludedConnectionID uint32, gatheringID uint32) uint32 {
-	sessionsMutex.RLock()
-	defer sessionsMutex.RUnlock()
+func (mm *MatchmakingManager) FindOtherConnectionID(excludedConnectionID uint32, gatheringID uint32) uint32 {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
 
-	return findOtherConnectionIDImpl(excludedConnectionID, gatheringID)
+	return mm.findOtherConnectionIDImpl(excludedConnectionID, gatheringID)
 }
 
-func removeSessionImpl(connection *nex.PRUDPConnection, gathering uint32) {
-	session, ok := sessions[gathering]
+func (mm *MatchmakingManager) removeSessionImpl(connection *nex.PRUDPConnection, gathering uint32, reason SessionEventReason) {
+	session, ok := mm.sessions.Get(gathering)
 	if !ok {
 		return
 	}
-	if session.ConnectionIDs.Size() != 0 {
-		endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
-		server := endpoint.Server
-
-		ownerPID := session.GameMatchmakeSession.Gathering.OwnerPID
-
+	if session.ConnectionIDs.Size() != 0 || session.SpectatorConnectionIDs.Size() != 0 {
 		category := notifications.NotificationCategories.GatheringUnregistered
 		subtype := notifications.NotificationSubTypes.GatheringUnregistered.None
 
-		oEvent := notifications_types.NewNotificationEvent()
-		oEvent.PIDSource = ownerPID
+		oEvent := NewNotificationEvent()
+		oEvent.PIDSource = session.GameMatchmakeSession.Gathering.OwnerPID
 		oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
-		oEvent.Param1 = types.NewPrimitiveU32(gathering)
-
-		stream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
-
-		oEvent.WriteTo(stream)
+		oEvent.Param1 = types.NewPrimitiveU64(uint64(gathering))
 
-		rmcRequest := nex.NewRMCRequest(endpoint)
-		rmcRequest.ProtocolID = notifications.ProtocolID
-		rmcRequest.CallID = CurrentMatchmakingCallID.Next()
-		rmcRequest.MethodID = notifications.MethodProcessNotificationEvent
-		rmcRequest.Parameters = stream.Bytes()
-
-		rmcRequestBytes := rmcRequest.Bytes()
-
-		session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
-			target := endpoint.FindConnectionByID(connectionID)
-			if target == nil {
-				Logger.Warning("Connection not found")
-				return false
-			}
-
-			var messagePacket nex.PRUDPPacketInterface
-
-			if target.DefaultPRUDPVersion == 0 {
-				messagePacket, _ = nex.NewPRUDPPacketV0(server, target, nil)
-			} else {
-				messagePacket, _ = nex.NewPRUDPPacketV1(server, target, nil)
-			}
-
-			messagePacket.SetType(constants.DataPacket)
-			messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-			messagePacket.AddFlag(constants.PacketFlagReliable)
-			messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
-			messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-			messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
-			messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
-			messagePacket.SetPayload(rmcRequestBytes)
-
-			server.Send(messagePacket)
-
-			return false
-		})
+		logNotificationDeliveryFailures(mm.sendNotificationEventToSessionImpl(session, oEvent))
 	}
 	
-	if SessionManagementDebugLog {
+	if mm.SessionManagementDebugLog {
 		globals.Logger.Infof("GID %d: Deleted", gathering)
 	}
 
-	for _, handler := range onSessionDeletedHandlers {
+	for _, handler := range mm.onSessionDeletedHandlers {
 		handler(gathering)
 	}
 
-	delete(sessions, gathering)
+	mm.emitSessionEventImpl(SessionEventTypes.Deleted, session, connection, reason)
+
+	if remoteParticipants, ok := mm.remoteParticipants[gathering]; ok {
+		delete(mm.remoteParticipants, gathering)
+
+		// * Their instances are notified outside of the lock, as it goes through the directory
+		go mm.notifyRemoteParticipantsUnregistered(gathering, remoteParticipants)
+	}
+
+	mm.clearInvitations(gathering)
+	mm.sessions.Delete(gathering)
 }
 
-func RemoveSession(connection *nex.PRUDPConnection, gathering uint32) {
-	sessionsMutex.Lock()
-	defer sessionsMutex.Unlock()
+func (mm *MatchmakingManager) RemoveSession(connection *nex.PRUDPConnection, gathering uint32) {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
 
-	removeSessionImpl(connection, gathering)
+	mm.removeSessionImpl(connection, gathering, SessionEventReasons.Graceful)
 }
 
 // RemoveConnectionIDFromSession removes a PRUDP connection from the session
-func removeConnectionIDFromSessionImpl(connection *nex.PRUDPConnection, gathering uint32, gracefully bool) {
-	session, ok := sessions[gathering]
+func (mm *MatchmakingManager) removeConnectionIDFromSessionImpl(connection *nex.PRUDPConnection, gathering uint32, reason SessionEventReason) {
+	session, ok := mm.sessions.Get(gathering)
 	if !ok {
 		return
 	}
 
-	for _, handler := range onPlayerLeaveSessionHandlers {
+	// * Spectators leave without the participants noticing
+	if session.SpectatorConnectionIDs.Has(connection.ID) {
+		mm.removeSpectatorFromSessionImpl(connection, session, reason)
+		return
+	}
+
+	gracefully := reason != SessionEventReasons.Disconnect
+
+	for _, handler := range mm.onPlayerLeaveSessionHandlers {
 		handler(gathering, connection.ID, gracefully)
 	}
 
 	session.ConnectionIDs.DeleteAll(connection.ID)
+	session.releaseSlots(connection.ID)
+
+	mm.emitSessionEventImpl(SessionEventTypes.PlayerLeft, session, connection, reason)
 
 	ownerPID := session.GameMatchmakeSession.Gathering.OwnerPID
 	lenParticipants := session.ConnectionIDs.Size()
 
-	if SessionManagementDebugLog {
+	if mm.SessionManagementDebugLog {
 		var grace string
 		if gracefully {
 			grace = "gracefully"
@@ -220,7 +200,7 @@ func removeConnectionIDFromSessionImpl(connection *nex.PRUDPConnection, gatherin
 
 	// * If there are no more participants, remove the session
 	if lenParticipants == 0 {
-		removeSessionImpl(connection, gathering)
+		mm.removeSessionImpl(connection, gathering, reason)
 		return
 	}
 
@@ -230,15 +210,17 @@ func removeConnectionIDFromSessionImpl(connection *nex.PRUDPConnection, gatherin
 		// * If the flag is not set, delete the session
 		// * More info: https://nintendo-wiki.pretendo.network/docs/nex/protocols/match-making/types#flags
 		if session.GameMatchmakeSession.Gathering.Flags.PAND(match_making.GatheringFlags.DisconnectChangeOwner) == 0 {
-			removeSessionImpl(connection, gathering)
+			mm.removeSessionImpl(connection, gathering, reason)
 			return
 		} else {
-			changeSessionOwnerImpl(connection, gathering, true)
+			mm.changeSessionOwnerImpl(connection, gathering, true)
 		}
 	}
 
-	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
-	server := endpoint.Server
+	// * The remaining participants are expected to pick a new host, but the server takes over if they don't
+	if session.GameMatchmakeSession.Gathering.HostPID.Equals(connection.PID()) {
+		mm.scheduleHostMigration(gathering, connection.PID())
+	}
 
 	category := notifications.NotificationCategories.Participation
 
@@ -249,113 +231,103 @@ func removeConnectionIDFromSessionImpl(connection *nex.PRUDPConnection, gatherin
 		subtype = notifications.NotificationSubTypes.Participation.Disconnected
 	}
 
-	oEvent := notifications_types.NewNotificationEvent()
+	oEvent := NewNotificationEvent()
 	oEvent.PIDSource = connection.PID()
 	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
-	oEvent.Param1 = types.NewPrimitiveU32(gathering)
-	oEvent.Param2 = types.NewPrimitiveU32(connection.PID().LegacyValue()) // TODO - This assumes a legacy client. This won't work on the Switch
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gathering))
+	oEvent.Param2 = types.NewPrimitiveU64(connection.PID().Value())
 
-	stream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
-
-	oEvent.WriteTo(stream)
-
-	rmcRequest := nex.NewRMCRequest(endpoint)
-	rmcRequest.ProtocolID = notifications.ProtocolID
-	rmcRequest.CallID = CurrentMatchmakingCallID.Next()
-	rmcRequest.MethodID = notifications.MethodProcessNotificationEvent
-	rmcRequest.Parameters = stream.Bytes()
-
-	rmcRequestBytes := rmcRequest.Bytes()
-
-	target := endpoint.FindConnectionByPID(ownerPID.Value())
-	if target == nil {
-		Logger.Warning("Target connection not found")
+	err := mm.SendNotificationEventToPID(ownerPID, oEvent)
+	if err != nil {
+		Logger.Warning(err.Error())
 		return
 	}
 
-	var messagePacket nex.PRUDPPacketInterface
-
-	if connection.DefaultPRUDPVersion == 0 {
-		messagePacket, _ = nex.NewPRUDPPacketV0(server, target, nil)
-	} else {
-		messagePacket, _ = nex.NewPRUDPPacketV1(server, target, nil)
-	}
-
-	messagePacket.SetType(constants.DataPacket)
-	messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-	messagePacket.AddFlag(constants.PacketFlagReliable)
-	messagePacket.SetSourceVirtualPortStreamType(connection.StreamType)
-	messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-	messagePacket.SetDestinationVirtualPortStreamType(connection.StreamType)
-	messagePacket.SetDestinationVirtualPortStreamID(connection.StreamID)
-	messagePacket.SetPayload(rmcRequestBytes)
-
-	server.Send(messagePacket)
-
 	// * Update the participation count with the new connection ID count
-	session.GameMatchmakeSession.ParticipationCount.Value = uint32(session.ConnectionIDs.Size())
+	session.GameMatchmakeSession.ParticipationCount.Value = uint32(mm.sessionParticipantCountImpl(session))
 }
 
-func RemoveConnectionIDFromSession(connection *nex.PRUDPConnection, gathering uint32, gracefully bool) {
-	sessionsMutex.Lock()
-	defer sessionsMutex.Unlock()
+func (mm *MatchmakingManager) RemoveConnectionIDFromSession(connection *nex.PRUDPConnection, gathering uint32, gracefully bool) {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	reason := SessionEventReasons.Graceful
+	if !gracefully {
+		reason = SessionEventReasons.Disconnect
+	}
 
-	removeConnectionIDFromSessionImpl(connection, gathering, gracefully)
+	mm.removeConnectionIDFromSessionImpl(connection, gathering, reason)
 }
 
 // FindConnectionSession searches for session the given connection ID is connected to
-func findConnectionSessionImpl(id uint32) uint32 {
-	for gatheringID := range sessions {
-		if sessions[gatheringID].ConnectionIDs.Has(id) {
-			return gatheringID
+func (mm *MatchmakingManager) findConnectionSessionImpl(id uint32) uint32 {
+	var foundGatheringID uint32 = 0
+	mm.sessions.Each(func(gatheringID uint32, session *CommonMatchmakeSession) bool {
+		if session.ConnectionIDs.Has(id) {
+			foundGatheringID = gatheringID
+			return true
 		}
-	}
 
-	return 0
+		return false
+	})
+
+	return foundGatheringID
 }
 
-func FindConnectionSession(id uint32) uint32 {
-	sessionsMutex.RLock()
-	defer sessionsMutex.RUnlock()
+func (mm *MatchmakingManager) FindConnectionSession(id uint32) uint32 {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
 
-	return findConnectionSessionImpl(id)
+	return mm.findConnectionSessionImpl(id)
 }
 
 // RemoveConnectionFromAllsessions removes a connection from every session
-func RemoveConnectionFromAllSessions(connection *nex.PRUDPConnection) {
-	sessionsMutex.Lock()
-	defer sessionsMutex.Unlock()
+func (mm *MatchmakingManager) RemoveConnectionFromAllSessions(connection *nex.PRUDPConnection) {
+	mm.leaveRemoteSessions(connection, false)
+
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
 
 	// * Keep checking until no session is found
-	for gid := findConnectionSessionImpl(connection.ID); gid != 0; {
+	for gid := mm.findConnectionSessionImpl(connection.ID); gid != 0; {
 
-		removeConnectionIDFromSessionImpl(connection, gid, false)
+		mm.removeConnectionIDFromSessionImpl(connection, gid, SessionEventReasons.Disconnect)
 
-		gid = findConnectionSessionImpl(connection.ID)
+		gid = mm.findConnectionSessionImpl(connection.ID)
 	}
+
+	mm.removeSpectatorFromAllSessionsImpl(connection, SessionEventReasons.Disconnect)
 }
 
 // CreateSessionByMatchmakeSession creates a gathering from a MatchmakeSession
-func CreateSessionByMatchmakeSession(matchmakeSession *match_making_types.MatchmakeSession, searchMatchmakeSession *match_making_types.MatchmakeSession, hostPID *types.PID) (*CommonMatchmakeSession, *nex.Error) {
-	sessionsMutex.Lock()
-	defer sessionsMutex.Unlock()
+func (mm *MatchmakingManager) CreateSessionByMatchmakeSession(matchmakeSession *match_making_types.MatchmakeSession, searchMatchmakeSession *match_making_types.MatchmakeSession, hostPID *types.PID) (*CommonMatchmakeSession, *nex.Error) {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
 	
-	sessionIndex := GetAvailableGatheringID()
+	sessionIndex := mm.GetAvailableGatheringID()
 	if sessionIndex == 0 {
-		sessionIndex = GetAvailableGatheringID() // * Skip to index 1
+		sessionIndex = mm.GetAvailableGatheringID() // * Skip to index 1
 	}
 
 	session := CommonMatchmakeSession{
 		SearchMatchmakeSession: searchMatchmakeSession,
 		GameMatchmakeSession:   matchmakeSession,
 		ConnectionIDs:          nex.NewMutexSlice[uint32](),
+		SpectatorConnectionIDs: nex.NewMutexSlice[uint32](),
 	}
 
+	// * Only the server needs to know the password itself
+	session.UserPassword = session.GameMatchmakeSession.UserPassword.Value
+	session.GameMatchmakeSession.UserPassword = types.NewString("")
+	session.GameMatchmakeSession.UserPasswordEnabled = types.NewPrimitiveBool(session.UserPassword != "")
+	session.GameMatchmakeSession.SystemPasswordEnabled = types.NewPrimitiveBool(false)
+
 	session.GameMatchmakeSession.Gathering.ID = types.NewPrimitiveU32(sessionIndex)
 	session.GameMatchmakeSession.Gathering.OwnerPID = hostPID
 	session.GameMatchmakeSession.Gathering.HostPID = hostPID
 
 	session.GameMatchmakeSession.StartedTime = types.NewDateTime(0).Now()
+	session.MarkActivity()
 	session.GameMatchmakeSession.SessionKey = types.NewBuffer(make([]byte, 32))
 
 	rand.Read(session.GameMatchmakeSession.SessionKey.Value)
@@ -371,17 +343,19 @@ func CreateSessionByMatchmakeSession(matchmakeSession *match_making_types.Matchm
 	session.GameMatchmakeSession.MatchmakeParam.Params.Set(types.NewString("@SR"), SR)
 	session.GameMatchmakeSession.MatchmakeParam.Params.Set(types.NewString("@GIR"), GIR)
 
-	sessions[sessionIndex] = &session
+	mm.sessions.Set(sessionIndex, &session)
 
-	if SessionManagementDebugLog {
+	if mm.SessionManagementDebugLog {
 		globals.Logger.Infof("GID %d: Created", sessionIndex)
 	}
 
-	for _, handler := range onSessionCreatedHandlers {
+	for _, handler := range mm.onSessionCreatedHandlers {
 		handler(session.GameMatchmakeSession.ID.Value)
 	}
 
-	return sessions[sessionIndex], nil
+	mm.emitSessionEventImpl(SessionEventTypes.Created, &session, mm.Endpoint.FindConnectionByPID(hostPID.Value()), SessionEventReasons.None)
+
+	return &session, nil
 }
 
 // isSessionHostConnected checks if the current session host is connected
@@ -395,47 +369,54 @@ func isSessionHostConnected(session *CommonMatchmakeSession, endpoint *nex.PRUDP
 	})
 }
 
-// FindSessionByMatchmakeSession finds a gathering that matches with a MatchmakeSession
-func FindSessionByMatchmakeSession(connection *nex.PRUDPConnection, searchMatchmakeSession *match_making_types.MatchmakeSession, dirtySearchMatchmakeSession *match_making_types.MatchmakeSession) uint32 {
-	sessionsMutex.RLock()
-	defer sessionsMutex.RUnlock()
+// FindSessionByMatchmakeSession finds a gathering that matches with a MatchmakeSession and has enough free slots for the whole party
+func (mm *MatchmakingManager) FindSessionByMatchmakeSession(connection *nex.PRUDPConnection, searchMatchmakeSession *match_making_types.MatchmakeSession, dirtySearchMatchmakeSession *match_making_types.MatchmakeSession, participationCount uint16) uint32 {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
 
 	// * This portion finds any sessions that match the search session
 	// * It does not care about anything beyond that, such as if the match is already full
 	// * This is handled below
-	candidateSessionIndexes := make([]uint32, 0, len(sessions))
-	for index, session := range sessions {
+	candidateSessionIndexes := make([]uint32, 0, mm.sessions.Len())
+	mm.sessions.Each(func(index uint32, session *CommonMatchmakeSession) bool {
 		if !session.SearchMatchmakeSession.Equals(searchMatchmakeSession) {
-			continue
+			return false
+		}
+
+		// * Password protected sessions can only be joined explicitly
+		if session.GameMatchmakeSession.UserPasswordEnabled.Value || session.GameMatchmakeSession.SystemPasswordEnabled.Value {
+			return false
 		}
 		// * Do not find the session if the host is not currently connected
 		if !isSessionHostConnected(session, connection.Endpoint().(*nex.PRUDPEndPoint)) {
-			continue
+			return false
 		}
 
 		// * Do not find the room if the requesting connection is the host. This means
 		// * the host was disconnected but the room host PID wasn't updated yet by the rest of
 		// * the clients. The host suddenly being available again causes issues.
 		if (session.GameMatchmakeSession.HostPID.Equals(connection.PID())) {
-			continue
+			return false
 		}
 
 		candidateSessionIndexes = append(candidateSessionIndexes, index)
-	}
+		return false
+	})
 
-	for _, handler := range filterFoundCandidateSessions {
+	for _, handler := range mm.filterFoundCandidateSessions {
 		candidateSessionIndexes = handler(candidateSessionIndexes, connection, dirtySearchMatchmakeSession)
 	}
 
-	// TODO - This whole section assumes legacy clients. None of it will work on the Switch
-	var friendList []uint32
+	var friendList []*types.PID
+	blockLists := mm.newBlockListCache()
+	suitableSessions := make([]*CommonMatchmakeSession, 0, len(candidateSessionIndexes))
 	for _, sessionIndex := range candidateSessionIndexes {
-		sessionToCheck, ok := sessions[sessionIndex]
+		sessionToCheck, ok := mm.sessions.Get(sessionIndex)
 		if !ok {
 			continue
 		}
 
-		if sessionToCheck.ConnectionIDs.Size() >= int(sessionToCheck.GameMatchmakeSession.MaximumParticipants.Value) {
+		if mm.sessionParticipantCountImpl(sessionToCheck)+int(participationCount) > int(sessionToCheck.GameMatchmakeSession.MaximumParticipants.Value) {
 			continue
 		}
 
@@ -443,114 +424,214 @@ func FindSessionByMatchmakeSession(connection *nex.PRUDPConnection, searchMatchm
 			continue
 		}
 
-		// * If the session only allows friends, check if the owner is in the friend list of the PID
-		// TODO - Is this a flag or a constant?
-		if sessionToCheck.GameMatchmakeSession.ParticipationPolicy.Value == 98 {
-			if GetUserFriendPIDsHandler == nil {
-				Logger.Warning("Missing GetUserFriendPIDsHandler!")
-				continue
-			}
+		if !mm.canParticipateImpl(connection, sessionToCheck, &friendList) {
+			continue
+		}
 
-			if len(friendList) == 0 {
-				friendList = GetUserFriendPIDsHandler(connection.PID().LegacyValue()) // TODO - This grpc method needs to support the Switch
-			}
+		if mm.isBlockedFromSessionImpl(connection, sessionToCheck, blockLists) {
+			continue
+		}
 
-			if !slices.Contains(friendList, sessionToCheck.GameMatchmakeSession.OwnerPID.LegacyValue()) {
-				continue
-			}
+		if isBannedFromSessionImpl(connection, sessionToCheck) {
+			continue
 		}
 
-		return sessionIndex // * Found a match
+		// * Without ranking, the first match is as good as any other
+		if mm.SessionRanking == nil {
+			return sessionIndex
+		}
+
+		suitableSessions = append(suitableSessions, sessionToCheck)
+	}
+
+	if len(suitableSessions) == 0 {
+		return 0
 	}
 
-	return 0
+	return mm.rankSessionsImpl(connection, suitableSessions)[0].GameMatchmakeSession.ID.Value
 }
 
-// FindSessionsByMatchmakeSessionSearchCriterias finds a gathering that matches with the given search criteria
-func FindSessionsByMatchmakeSessionSearchCriterias(connection *nex.PRUDPConnection, searchCriterias []*match_making_types.MatchmakeSessionSearchCriteria, gameSpecificChecks func(searchCriteria *match_making_types.MatchmakeSessionSearchCriteria, matchmakeSession *match_making_types.MatchmakeSession) bool) []*CommonMatchmakeSession {
-	sessionsMutex.RLock()
-	defer sessionsMutex.RUnlock()
-	
-	candidateSessions := make([]*CommonMatchmakeSession, 0, len(sessions))
+// findSessionGroupsBySearchCriteriasImpl finds the sessions that match with the given search criterias.
+// The sessions are grouped by the first search criteria they match with, and each group is
+// sorted using SessionOrder and the selection method of its search criteria.
+// The given sessions of other instances are grouped and sorted along with the local ones
+func (mm *MatchmakingManager) findSessionGroupsBySearchCriteriasImpl(connection *nex.PRUDPConnection, searchCriterias []*match_making_types.MatchmakeSessionSearchCriteria, gameSpecificChecks func(searchCriteria *match_making_types.MatchmakeSessionSearchCriteria, matchmakeSession *match_making_types.MatchmakeSession) bool, remoteSessions []*CommonMatchmakeSession) [][]*CommonMatchmakeSession {
+	sessionGroups := make([][]*CommonMatchmakeSession, len(searchCriterias))
 
-	// TODO - This whole section assumes legacy clients. None of it will work on the Switch
-	var friendList []uint32
-	for _, session := range sessions {
+	var friendList []*types.PID
+	blockLists := mm.newBlockListCache()
+	mm.sessions.Each(func(_ uint32, session *CommonMatchmakeSession) bool {
 		// * Do not find the session if the host is not currently connected
 		if !isSessionHostConnected(session, connection.Endpoint().(*nex.PRUDPEndPoint)) {
-			continue
+			return false
 		}
 
 		// * Do not find the room if the requesting connection is the host. This means
 		// * the host was disconnected but the room host PID wasn't updated yet by the rest of
 		// * the clients. The host suddenly being available again causes issues.
 		if (session.GameMatchmakeSession.HostPID.Equals(connection.PID())) {
-			continue
+			return false
 		}
 
-		for _, criteria := range searchCriterias {
-			// * Check things like game specific attributes
-			if gameSpecificChecks != nil {
-				if !gameSpecificChecks(criteria, session.GameMatchmakeSession) {
-					continue
-				}
-			} else {
-				if !compareAttributesSearchCriteria(session.GameMatchmakeSession.Attributes.Slice(), criteria.Attribs.Slice()) {
-					continue
-				}
-			}
+		if mm.isBlockedFromSessionImpl(connection, session, blockLists) {
+			return false
+		}
 
-			if !compareSearchCriteria(session.GameMatchmakeSession.MaximumParticipants.Value, criteria.MaxParticipants.Value) {
-				continue
-			}
+		if isBannedFromSessionImpl(connection, session) {
+			return false
+		}
 
-			if !compareSearchCriteria(session.GameMatchmakeSession.MinimumParticipants.Value, criteria.MinParticipants.Value) {
+		for criteriaIndex, criteria := range searchCriterias {
+			if !matchesSearchCriteria(session.GameMatchmakeSession, mm.sessionParticipantCountImpl(session), criteria, gameSpecificChecks) {
 				continue
 			}
 
-			if !compareSearchCriteria(session.GameMatchmakeSession.MatchmakeSystemType.Value, criteria.MatchmakeSystemType.Value) {
+			if !mm.canParticipateImpl(connection, session, &friendList) {
 				continue
 			}
 
-			if !compareSearchCriteria(session.GameMatchmakeSession.GameMode.Value, criteria.GameMode.Value) {
-				continue
-			}
+			sessionGroups[criteriaIndex] = append(sessionGroups[criteriaIndex], session)
 
-			if session.ConnectionIDs.Size() >= int(session.GameMatchmakeSession.MaximumParticipants.Value) {
-				continue
-			}
+			// * We don't have to compare with other search criterias
+			break
+		}
+
+		return false
+	})
 
-			if !session.GameMatchmakeSession.OpenParticipation.Value {
+	// * The participants of remote sessions are unknown, so they only go through the checks which don't need them
+	for _, session := range remoteSessions {
+		for criteriaIndex, criteria := range searchCriterias {
+			if !matchesSearchCriteria(session.GameMatchmakeSession, session.OccupiedSlots(), criteria, gameSpecificChecks) {
 				continue
 			}
 
-			// * If the session only allows friends, check if the owner is in the friend list of the PID
-			// TODO - Is this a flag or a constant?
-			if session.GameMatchmakeSession.ParticipationPolicy.Value == 98 {
-				if GetUserFriendPIDsHandler == nil {
-					Logger.Warning("Missing GetUserFriendPIDsHandler!")
-					continue
-				}
-
-				if len(friendList) == 0 {
-					friendList = GetUserFriendPIDsHandler(connection.PID().LegacyValue()) // TODO - Support the Switch
-				}
-
-				if !slices.Contains(friendList, session.GameMatchmakeSession.OwnerPID.LegacyValue()) {
-					continue
-				}
+			if !mm.canParticipateImpl(connection, session, &friendList) {
+				continue
 			}
 
-			candidateSessions = append(candidateSessions, session)
-
-			// * We don't have to compare with other search criterias
+			sessionGroups[criteriaIndex] = append(sessionGroups[criteriaIndex], session)
 			break
 		}
 	}
 
+	for criteriaIndex, sessionGroup := range sessionGroups {
+		mm.sortSessionsImpl(sessionGroup)
+		mm.applySelectionMethodImpl(sessionGroup, searchCriterias[criteriaIndex])
+	}
+
+	return sessionGroups
+}
+
+// FindSessionsByMatchmakeSessionSearchCriterias finds the gatherings that match with the given search criterias.
+// The sessions matching an earlier search criteria are placed first
+func (mm *MatchmakingManager) FindSessionsByMatchmakeSessionSearchCriterias(connection *nex.PRUDPConnection, searchCriterias []*match_making_types.MatchmakeSessionSearchCriteria, gameSpecificChecks func(searchCriteria *match_making_types.MatchmakeSessionSearchCriteria, matchmakeSession *match_making_types.MatchmakeSession) bool) []*CommonMatchmakeSession {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	candidateSessions := make([]*CommonMatchmakeSession, 0)
+	for _, sessionGroup := range mm.findSessionGroupsBySearchCriteriasImpl(connection, searchCriterias, gameSpecificChecks, nil) {
+		candidateSessions = append(candidateSessions, sessionGroup...)
+	}
+
 	return candidateSessions
 }
 
+
+// matchesSearchCriteria checks a MatchmakeSession with the given number of participants against the search criteria
+func matchesSearchCriteria(matchmakeSession *match_making_types.MatchmakeSession, participants int, criteria *match_making_types.MatchmakeSessionSearchCriteria, gameSpecificChecks func(searchCriteria *match_making_types.MatchmakeSessionSearchCriteria, matchmakeSession *match_making_types.MatchmakeSession) bool) bool {
+	// * Check things like game specific attributes
+	if gameSpecificChecks != nil {
+		if !gameSpecificChecks(criteria, matchmakeSession) {
+			return false
+		}
+	} else {
+		if !compareAttributesSearchCriteria(matchmakeSession.Attributes.Slice(), criteria.Attribs.Slice()) {
+			return false
+		}
+	}
+
+	if !compareSearchCriteria(matchmakeSession.MaximumParticipants.Value, criteria.MaxParticipants.Value) {
+		return false
+	}
+
+	if !compareSearchCriteria(matchmakeSession.MinimumParticipants.Value, criteria.MinParticipants.Value) {
+		return false
+	}
+
+	if !compareSearchCriteria(matchmakeSession.MatchmakeSystemType.Value, criteria.MatchmakeSystemType.Value) {
+		return false
+	}
+
+	if !compareSearchCriteria(matchmakeSession.GameMode.Value, criteria.GameMode.Value) {
+		return false
+	}
+
+	return compareSearchCriteriaFilters(matchmakeSession, participants, criteria)
+}
+
+// compareSearchCriteriaFilters checks the session against the filter flags of the search criteria
+func compareSearchCriteriaFilters(matchmakeSession *match_making_types.MatchmakeSession, participants int, criteria *match_making_types.MatchmakeSessionSearchCriteria) bool {
+	if criteria.VacantOnly.Value {
+		// * VacantParticipants is the number of free slots needed, 0 meaning at least one
+		vacantParticipants := int(criteria.VacantParticipants.Value)
+		if vacantParticipants == 0 {
+			vacantParticipants = 1
+		}
+
+		if int(matchmakeSession.MaximumParticipants.Value)-participants < vacantParticipants {
+			return false
+		}
+	}
+
+	if criteria.ExcludeLocked.Value && !matchmakeSession.OpenParticipation.Value {
+		return false
+	}
+
+	if criteria.ExcludeNonHostPID.Value && matchmakeSession.HostPID.Value() == 0 {
+		return false
+	}
+
+	if criteria.ExcludeUserPasswordSet.Value && matchmakeSession.UserPasswordEnabled.Value {
+		return false
+	}
+
+	if criteria.ExcludeSystemPasswordSet.Value && matchmakeSession.SystemPasswordEnabled.Value {
+		return false
+	}
+
+	// * Sessions with a code word can only be found by searching for it
+	if matchmakeSession.CodeWord.Value != criteria.CodeWord.Value {
+		return false
+	}
+
+	return true
+}
+
+// FilterJoinableSessions returns the sessions which are open for participation and have room for the given number of participants.
+// The slots reserved for guests count as taken. Password protected sessions are left out, as they can't be joined without knowing the password
+func FilterJoinableSessions(sessions []*CommonMatchmakeSession, participants int) []*CommonMatchmakeSession {
+	joinableSessions := make([]*CommonMatchmakeSession, 0, len(sessions))
+
+	for _, session := range sessions {
+		if !session.GameMatchmakeSession.OpenParticipation.Value {
+			continue
+		}
+
+		if session.GameMatchmakeSession.UserPasswordEnabled.Value || session.GameMatchmakeSession.SystemPasswordEnabled.Value {
+			continue
+		}
+
+		if int(session.GameMatchmakeSession.MaximumParticipants.Value)-session.OccupiedSlots() < participants {
+			continue
+		}
+
+		joinableSessions = append(joinableSessions, session)
+	}
+
+	return joinableSessions
+}
+
 func compareAttributesSearchCriteria(original []*types.PrimitiveU32, search []*types.String) bool {
 	if len(original) != len(search) {
 		return false
@@ -597,93 +678,123 @@ func compareSearchCriteria[T ~uint16 | ~uint32](original T, search string) bool
 
 // AddPlayersToSession updates the given sessions state to include the provided connection IDs
 // Returns a NEX error code if failed
-func AddPlayersToSession(session *CommonMatchmakeSession, connectionIDs []uint32, initiatingConnection *nex.PRUDPConnection, joinMessage string) *nex.Error {
-	sessionsMutex.RLock()
-	defer sessionsMutex.RUnlock()
+func (mm *MatchmakingManager) AddPlayersToSession(session *CommonMatchmakeSession, connectionIDs []uint32, initiatingConnection *nex.PRUDPConnection, joinMessage string) *nex.Error {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	return mm.addPlayersToSessionImpl(session, connectionIDs, 0, initiatingConnection, joinMessage)
+}
+
+// AddPartyToSession adds the connection to the session along with its party.
+// The participation count includes the connection itself, and the rest of the slots are
+// reserved for its guest players and co-located friends until it leaves the session.
+// Returns a NEX error code if failed
+func (mm *MatchmakingManager) AddPartyToSession(session *CommonMatchmakeSession, connection *nex.PRUDPConnection, participationCount uint16, joinMessage string) *nex.Error {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	// * Some clients send 0 when playing alone
+	reservedSlots := 0
+	if participationCount > 1 {
+		reservedSlots = int(participationCount) - 1
+	}
+
+	return mm.addPlayersToSessionImpl(session, []uint32{connection.ID}, reservedSlots, connection, joinMessage)
+}
 
-	if (session.ConnectionIDs.Size() + len(connectionIDs)) > int(session.GameMatchmakeSession.Gathering.MaximumParticipants.Value) {
+// addPlayersToSessionImpl adds the connection IDs to the session, and reserves extra slots for the guests of the initiating connection.
+// Requires sessionsMutex to be locked
+func (mm *MatchmakingManager) addPlayersToSessionImpl(session *CommonMatchmakeSession, connectionIDs []uint32, reservedSlots int, initiatingConnection *nex.PRUDPConnection, joinMessage string) *nex.Error {
+	partySize := len(connectionIDs) + reservedSlots
+
+	if (mm.sessionParticipantCountImpl(session) + partySize) > int(session.GameMatchmakeSession.Gathering.MaximumParticipants.Value) {
 		return nex.NewError(nex.ResultCodes.RendezVous.SessionFull, fmt.Sprintf("Gathering %d is full", session.GameMatchmakeSession.Gathering.ID))
 	}
 
 	// * TOCTOU, just in case
-	_, ok := sessions[session.GameMatchmakeSession.Gathering.ID.Value]
+	_, ok := mm.sessions.Get(session.GameMatchmakeSession.Gathering.ID.Value)
 	if !ok {
 		return nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
 
+	for _, connectedID := range connectionIDs {
+		if session.ConnectionIDs.Has(connectedID) {
+			return nex.NewError(nex.ResultCodes.RendezVous.AlreadyParticipatedGathering, fmt.Sprintf("Connection ID %d is already in gathering %d", connectedID, session.GameMatchmakeSession.Gathering.ID))
+		}
+	}
+
 	endpoint := initiatingConnection.Endpoint().(*nex.PRUDPEndPoint)
 	server := endpoint.Server
 
+	// * Reserve the guest slots first, so that the participation count is already right when the players are added
+	if reservedSlots > 0 {
+		session.reserveSlots(initiatingConnection.ID, reservedSlots)
+
+		if mm.SessionManagementDebugLog {
+			globals.Logger.Infof("GID %d: Reserved %d slots for the guests of PID %d", session.GameMatchmakeSession.Gathering.ID.Value, reservedSlots, initiatingConnection.PID().Value())
+		}
+	}
+
 	for _, connectedID := range connectionIDs {
-		if session.ConnectionIDs.Has(connectedID) {
-			return nex.NewError(nex.ResultCodes.RendezVous.AlreadyParticipatedGathering, fmt.Sprintf("Connection ID %d is already in gathering %d", connectedID, session.GameMatchmakeSession.Gathering.ID))
+		conn := endpoint.FindConnectionByID(connectedID)
+
+		// * A spectator joining the session stops spectating it
+		if session.SpectatorConnectionIDs.Has(connectedID) && conn != nil {
+			mm.removeSpectatorFromSessionImpl(conn, session, SessionEventReasons.None)
 		}
 
 		session.ConnectionIDs.Add(connectedID)
 
-		if SessionManagementDebugLog {
-			conn := endpoint.FindConnectionByID(connectedID)
+		if conn != nil {
+			// * The invitation has been used
+			mm.invitationsMutex.Lock()
+			mm.removeInvitationImpl(session.GameMatchmakeSession.Gathering.ID.Value, conn.PID())
+			mm.invitationsMutex.Unlock()
+		}
+
+		if mm.SessionManagementDebugLog {
 			globals.Logger.Infof("GID %d: Added PID %d", session.GameMatchmakeSession.Gathering.ID.Value, conn.PID().Value())
 		}
 
 		// * Update the participation count with the new connection ID count
-		session.GameMatchmakeSession.ParticipationCount.Value = uint32(session.ConnectionIDs.Size())
+		session.GameMatchmakeSession.ParticipationCount.Value = uint32(mm.sessionParticipantCountImpl(session))
 
-		for _, handler := range onPlayerJoinSessionHandlers {
+		for _, handler := range mm.onPlayerJoinSessionHandlers {
 			handler(session.GameMatchmakeSession.ID.Value, connectedID)
 		}
+
+		mm.emitSessionEventImpl(SessionEventTypes.PlayerJoined, session, conn, SessionEventReasons.None)
 	}
 
-	target := endpoint.FindConnectionByPID(session.GameMatchmakeSession.OwnerPID.Value())
-	if target != nil {
-		notificationCategory := notifications.NotificationCategories.Participation
-		notificationSubtype := notifications.NotificationSubTypes.Participation.NewParticipant
+	notificationCategory := notifications.NotificationCategories.Participation
+	notificationSubtype := notifications.NotificationSubTypes.Participation.NewParticipant
 
-		oEvent := notifications_types.NewNotificationEvent()
+	newParticipantEvent := func(participantPID *types.PID) *NotificationEvent {
+		oEvent := NewNotificationEvent()
 		oEvent.PIDSource = initiatingConnection.PID()
 		oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(notificationCategory, notificationSubtype))
-		oEvent.Param1 = session.GameMatchmakeSession.ID.Copy().(*types.PrimitiveU32)
-		oEvent.Param2 = types.NewPrimitiveU32(target.PID().LegacyValue()) // TODO - This assumes a legacy client. Will not work on the Switch
+		oEvent.Param1 = types.NewPrimitiveU64(uint64(session.GameMatchmakeSession.ID.Value))
+		oEvent.Param2 = types.NewPrimitiveU64(participantPID.Value())
 		oEvent.StrParam = types.NewString(joinMessage)
-		oEvent.Param3 = types.NewPrimitiveU32(uint32(len(connectionIDs)))
-
-		notificationStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
-
-		oEvent.WriteTo(notificationStream)
-
-		notificationRequest := nex.NewRMCRequest(endpoint)
-		notificationRequest.ProtocolID = notifications.ProtocolID
-		notificationRequest.CallID = CurrentMatchmakingCallID.Next()
-		notificationRequest.MethodID = notifications.MethodProcessNotificationEvent
-		notificationRequest.Parameters = notificationStream.Bytes()
+		oEvent.Param3 = types.NewPrimitiveU64(uint64(partySize))
 
-		notificationRequestBytes := notificationRequest.Bytes()
-
-		var messagePacket nex.PRUDPPacketInterface
+		return oEvent
+	}
 
-		if target.DefaultPRUDPVersion == 0 {
-			messagePacket, _ = nex.NewPRUDPPacketV0(server, target, nil)
-		} else {
-			messagePacket, _ = nex.NewPRUDPPacketV1(server, target, nil)
+	target := endpoint.FindConnectionByPID(session.GameMatchmakeSession.OwnerPID.Value())
+	if target != nil {
+		err := mm.SendNotificationEvent(target, newParticipantEvent(target.PID()))
+		if err != nil {
+			Logger.Warning(err.Error())
 		}
-
-		messagePacket.SetType(constants.DataPacket)
-		messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-		messagePacket.AddFlag(constants.PacketFlagReliable)
-		messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
-		messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-		messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
-		messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
-		messagePacket.SetPayload(notificationRequestBytes)
-
-		server.Send(messagePacket)
-	}	
+	}
 
 	// * This appears to be correct. Tri-Force Heroes uses 3.9.0,
 	// * and has issues if these notifications are sent.
 	// * Minecraft, however, requires these to be sent
 	// TODO - Check other games both pre and post 3.10.0 and validate
 	if server.LibraryVersions.MatchMaking.GreaterOrEqual("3.10.0") {
+		// * Tell the joining connection about every participant in the session
 		session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
 			target := endpoint.FindConnectionByID(connectionID)
 			if target == nil {
@@ -692,141 +803,42 @@ func AddPlayersToSession(session *CommonMatchmakeSession, connectionIDs []uint32
 				return false
 			}
 
-			notificationCategory := notifications.NotificationCategories.Participation
-			notificationSubtype := notifications.NotificationSubTypes.Participation.NewParticipant
-
-			oEvent := notifications_types.NewNotificationEvent()
-			oEvent.PIDSource = initiatingConnection.PID()
-			oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(notificationCategory, notificationSubtype))
-			oEvent.Param1 = session.GameMatchmakeSession.ID.Copy().(*types.PrimitiveU32)
-			oEvent.Param2 = types.NewPrimitiveU32(target.PID().LegacyValue()) // TODO - This assumes a legacy client. Will not work on the Switch
-			oEvent.StrParam = types.NewString(joinMessage)
-			oEvent.Param3 = types.NewPrimitiveU32(uint32(len(connectionIDs)))
-
-			notificationStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
-
-			oEvent.WriteTo(notificationStream)
-
-			notificationRequest := nex.NewRMCRequest(endpoint)
-			notificationRequest.ProtocolID = notifications.ProtocolID
-			notificationRequest.CallID = CurrentMatchmakingCallID.Next()
-			notificationRequest.MethodID = notifications.MethodProcessNotificationEvent
-			notificationRequest.Parameters = notificationStream.Bytes()
-
-			notificationRequestBytes := notificationRequest.Bytes()
-
-			var messagePacket nex.PRUDPPacketInterface
-
-			if target.DefaultPRUDPVersion == 0 {
-				messagePacket, _ = nex.NewPRUDPPacketV0(server, initiatingConnection, nil)
-			} else {
-				messagePacket, _ = nex.NewPRUDPPacketV1(server, initiatingConnection, nil)
+			err := mm.SendNotificationEvent(initiatingConnection, newParticipantEvent(target.PID()))
+			if err != nil {
+				Logger.Warning(err.Error())
 			}
 
-			messagePacket.SetType(constants.DataPacket)
-			messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-			messagePacket.AddFlag(constants.PacketFlagReliable)
-			messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
-			messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-			messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
-			messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
-			messagePacket.SetPayload(notificationRequestBytes)
-
-			server.Send(messagePacket)
-
 			return false
 		})
 
-		notificationCategory := notifications.NotificationCategories.Participation
-		notificationSubtype := notifications.NotificationSubTypes.Participation.NewParticipant
-
-		oEvent := notifications_types.NewNotificationEvent()
-		oEvent.PIDSource = initiatingConnection.PID()
-		oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(notificationCategory, notificationSubtype))
-		oEvent.Param1 = session.GameMatchmakeSession.ID
-		oEvent.Param2 = types.NewPrimitiveU32(initiatingConnection.PID().LegacyValue()) // TODO - This assumes a legacy client. Will not work on the Switch
-		oEvent.StrParam = types.NewString(joinMessage)
-		oEvent.Param3 = types.NewPrimitiveU32(uint32(len(connectionIDs)))
+		oEvent := newParticipantEvent(initiatingConnection.PID())
 
-		notificationStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
-
-		oEvent.WriteTo(notificationStream)
-
-		notificationRequest := nex.NewRMCRequest(endpoint)
-		notificationRequest.ProtocolID = notifications.ProtocolID
-		notificationRequest.CallID = CurrentMatchmakingCallID.Next()
-		notificationRequest.MethodID = notifications.MethodProcessNotificationEvent
-		notificationRequest.Parameters = notificationStream.Bytes()
-
-		notificationRequestBytes := notificationRequest.Bytes()
-
-		var messagePacket nex.PRUDPPacketInterface
-
-		if initiatingConnection.DefaultPRUDPVersion == 0 {
-			messagePacket, _ = nex.NewPRUDPPacketV0(server, initiatingConnection, nil)
-		} else {
-			messagePacket, _ = nex.NewPRUDPPacketV1(server, initiatingConnection, nil)
+		err := mm.SendNotificationEvent(initiatingConnection, oEvent)
+		if err != nil {
+			Logger.Warning(err.Error())
 		}
 
-		messagePacket.SetType(constants.DataPacket)
-		messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-		messagePacket.AddFlag(constants.PacketFlagReliable)
-		messagePacket.SetSourceVirtualPortStreamType(initiatingConnection.StreamType)
-		messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-		messagePacket.SetDestinationVirtualPortStreamType(initiatingConnection.StreamType)
-		messagePacket.SetDestinationVirtualPortStreamID(initiatingConnection.StreamID)
-		messagePacket.SetPayload(notificationRequestBytes)
-
-		server.Send(messagePacket)
-
-		target := endpoint.FindConnectionByPID(session.GameMatchmakeSession.Gathering.OwnerPID.Value())
-		if target == nil {
+		err = mm.SendNotificationEventToPID(session.GameMatchmakeSession.Gathering.OwnerPID, oEvent)
+		if err != nil {
 			// TODO - Error here?
-			Logger.Warning("Player not found")
+			Logger.Warning(err.Error())
 			return nil
 		}
-
-		if target.DefaultPRUDPVersion == 0 {
-			messagePacket, _ = nex.NewPRUDPPacketV0(server, target, nil)
-		} else {
-			messagePacket, _ = nex.NewPRUDPPacketV1(server, target, nil)
-		}
-
-		messagePacket.SetType(constants.DataPacket)
-		messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-		messagePacket.AddFlag(constants.PacketFlagReliable)
-		messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
-		messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-		messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
-		messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
-		messagePacket.SetPayload(notificationRequestBytes)
-
-		server.Send(messagePacket)
 	}
 
 	return nil
 }
 
 // ChangeSessionOwner changes the session owner to a different connection
-func changeSessionOwnerImpl(currentOwner *nex.PRUDPConnection, gathering uint32, isLeaving bool) {
-	endpoint := currentOwner.Endpoint().(*nex.PRUDPEndPoint)
-	server := endpoint.Server
-	session, ok := sessions[gathering]
+func (mm *MatchmakingManager) changeSessionOwnerImpl(currentOwner *nex.PRUDPConnection, gathering uint32, isLeaving bool) {
+	session, ok := mm.sessions.Get(gathering)
 	if !ok {
 		return
 	}
 
-	var newOwner *nex.PRUDPConnection
-
-	newOwnerConnectionID := findOtherConnectionIDImpl(currentOwner.ID, gathering)
-	if newOwnerConnectionID != 0 {
-		newOwner = endpoint.FindConnectionByID(newOwnerConnectionID)
-		if newOwner == nil {
-			Logger.Warning("Other connection not found")
-			return
-		}
-
-		if SessionManagementDebugLog {
+	newOwner := mm.selectHostImpl(session, currentOwner.ID)
+	if newOwner != nil {
+		if mm.SessionManagementDebugLog {
 			globals.Logger.Infof("GID %d: ChangeSessionOwner OWNER from PID %d to PID %d", gathering, currentOwner.PID().Value(), newOwner.PID().Value())
 		}
 	
@@ -835,65 +847,44 @@ func changeSessionOwnerImpl(currentOwner *nex.PRUDPConnection, gathering uint32,
 		return
 	}
 
+	mm.emitSessionEventImpl(SessionEventTypes.OwnerChanged, session, newOwner, SessionEventReasons.Migration)
+
 	category := notifications.NotificationCategories.OwnershipChanged
 	subtype := notifications.NotificationSubTypes.OwnershipChanged.None
 
-	oEvent := notifications_types.NewNotificationEvent()
+	oEvent := NewNotificationEvent()
 	oEvent.PIDSource = currentOwner.PID()
 	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
-	oEvent.Param1 = types.NewPrimitiveU32(gathering)
-	oEvent.Param2 = types.NewPrimitiveU32(newOwner.PID().LegacyValue()) // TODO - This assumes a legacy client. Will not work on the Switch
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gathering))
+	oEvent.Param2 = types.NewPrimitiveU64(newOwner.PID().Value())
 
 	// TODO - StrParam doesn't have this value on some servers
 	// * https://github.com/kinnay/NintendoClients/issues/101
 	// * unixTime := time.Now()
 	// * oEvent.StrParam = strconv.FormatInt(unixTime.UnixMicro(), 10)
 
-	stream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
-
-	oEvent.WriteTo(stream)
-
-	rmcRequest := nex.NewRMCRequest(endpoint)
-	rmcRequest.ProtocolID = notifications.ProtocolID
-	rmcRequest.CallID = CurrentMatchmakingCallID.Next()
-	rmcRequest.MethodID = notifications.MethodProcessNotificationEvent
-	rmcRequest.Parameters = stream.Bytes()
-
-	rmcRequestBytes := rmcRequest.Bytes()
-
-	session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
-		target := endpoint.FindConnectionByID(connectionID)
-		if target == nil {
-			Logger.Warning("Connection not found")
-			return false
-		}
-
-		var messagePacket nex.PRUDPPacketInterface
+	logNotificationDeliveryFailures(mm.sendNotificationEventToSessionImpl(session, oEvent))
+}
 
-		if target.DefaultPRUDPVersion == 0 {
-			messagePacket, _ = nex.NewPRUDPPacketV0(server, target, nil)
-		} else {
-			messagePacket, _ = nex.NewPRUDPPacketV1(server, target, nil)
-		}
+func (mm *MatchmakingManager) ChangeSessionOwner(currentOwner *nex.PRUDPConnection, gathering uint32, isLeaving bool) {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
 
-		messagePacket.SetType(constants.DataPacket)
-		messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-		messagePacket.AddFlag(constants.PacketFlagReliable)
-		messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
-		messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-		messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
-		messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
-		messagePacket.SetPayload(rmcRequestBytes)
+	mm.changeSessionOwnerImpl(currentOwner, gathering, isLeaving)
+}
 
-		server.Send(messagePacket)
+// SetSessionOpenParticipation opens or closes the participation of the session
+// Returns a NEX error code if the session doesn't exist
+func (mm *MatchmakingManager) SetSessionOpenParticipation(gathering uint32, openParticipation bool) *nex.Error {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
 
-		return false
-	})
-}
+	session, ok := mm.sessions.Get(gathering)
+	if !ok {
+		return nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
 
-func ChangeSessionOwner(currentOwner *nex.PRUDPConnection, gathering uint32, isLeaving bool) {
-	sessionsMutex.RLock()
-	defer sessionsMutex.RUnlock()
+	session.GameMatchmakeSession.OpenParticipation = types.NewPrimitiveBool(openParticipation)
 
-	changeSessionOwnerImpl(currentOwner, gathering, isLeaving)
+	return nil
 }
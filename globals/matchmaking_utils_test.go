@@ -0,0 +1,235 @@
+package common_globals
+
+import (
+	"testing"
+
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// searchCriteriaFilterTest is a case of compareSearchCriteriaFilters. The session and criteria start from their defaults
+type searchCriteriaFilterTest struct {
+	name         string
+	participants int
+	session      func(matchmakeSession *match_making_types.MatchmakeSession)
+	criteria     func(criteria *match_making_types.MatchmakeSessionSearchCriteria)
+	expected     bool
+}
+
+func runSearchCriteriaFilterTests(t *testing.T, tests []searchCriteriaFilterTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matchmakeSession := match_making_types.NewMatchmakeSession()
+			matchmakeSession.MaximumParticipants = types.NewPrimitiveU16(4)
+			matchmakeSession.OpenParticipation = types.NewPrimitiveBool(true)
+			matchmakeSession.HostPID = types.NewPID(100)
+
+			if test.session != nil {
+				test.session(matchmakeSession)
+			}
+
+			criteria := match_making_types.NewMatchmakeSessionSearchCriteria()
+			if test.criteria != nil {
+				test.criteria(criteria)
+			}
+
+			result := compareSearchCriteriaFilters(matchmakeSession, test.participants, criteria)
+			if result != test.expected {
+				t.Errorf("compareSearchCriteriaFilters returned %t, expected %t", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestCompareSearchCriteriaFiltersVacantOnly(t *testing.T) {
+	vacantOnly := func(vacantParticipants uint16) func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+		return func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+			criteria.VacantOnly = types.NewPrimitiveBool(true)
+			criteria.VacantParticipants = types.NewPrimitiveU16(vacantParticipants)
+		}
+	}
+
+	runSearchCriteriaFilterTests(t, []searchCriteriaFilterTest{
+		{name: "full session without VacantOnly", participants: 4, expected: true},
+		{name: "free slot", participants: 3, criteria: vacantOnly(0), expected: true},
+		{name: "full session", participants: 4, criteria: vacantOnly(0), expected: false},
+		{name: "VacantParticipants 1 on full session", participants: 4, criteria: vacantOnly(1), expected: false},
+		{name: "enough slots for VacantParticipants", participants: 1, criteria: vacantOnly(3), expected: true},
+		{name: "not enough slots for VacantParticipants", participants: 2, criteria: vacantOnly(3), expected: false},
+		{name: "VacantParticipants ignored without VacantOnly", participants: 4, criteria: func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+			criteria.VacantParticipants = types.NewPrimitiveU16(2)
+		}, expected: true},
+	})
+}
+
+func TestCompareSearchCriteriaFiltersExcludeLocked(t *testing.T) {
+	locked := func(matchmakeSession *match_making_types.MatchmakeSession) {
+		matchmakeSession.OpenParticipation = types.NewPrimitiveBool(false)
+	}
+
+	excludeLocked := func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+		criteria.ExcludeLocked = types.NewPrimitiveBool(true)
+	}
+
+	runSearchCriteriaFilterTests(t, []searchCriteriaFilterTest{
+		{name: "locked session without ExcludeLocked", session: locked, expected: true},
+		{name: "open session", criteria: excludeLocked, expected: true},
+		{name: "locked session", session: locked, criteria: excludeLocked, expected: false},
+	})
+}
+
+func TestCompareSearchCriteriaFiltersExcludeNonHostPID(t *testing.T) {
+	noHost := func(matchmakeSession *match_making_types.MatchmakeSession) {
+		matchmakeSession.HostPID = types.NewPID(0)
+	}
+
+	excludeNonHostPID := func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+		criteria.ExcludeNonHostPID = types.NewPrimitiveBool(true)
+	}
+
+	runSearchCriteriaFilterTests(t, []searchCriteriaFilterTest{
+		{name: "session without host without ExcludeNonHostPID", session: noHost, expected: true},
+		{name: "session with host", criteria: excludeNonHostPID, expected: true},
+		{name: "session without host", session: noHost, criteria: excludeNonHostPID, expected: false},
+	})
+}
+
+func TestCompareSearchCriteriaFiltersExcludeUserPasswordSet(t *testing.T) {
+	userPassword := func(matchmakeSession *match_making_types.MatchmakeSession) {
+		matchmakeSession.UserPasswordEnabled = types.NewPrimitiveBool(true)
+	}
+
+	excludeUserPasswordSet := func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+		criteria.ExcludeUserPasswordSet = types.NewPrimitiveBool(true)
+	}
+
+	runSearchCriteriaFilterTests(t, []searchCriteriaFilterTest{
+		{name: "user password without ExcludeUserPasswordSet", session: userPassword, expected: true},
+		{name: "no user password", criteria: excludeUserPasswordSet, expected: true},
+		{name: "user password", session: userPassword, criteria: excludeUserPasswordSet, expected: false},
+		{name: "system password", session: func(matchmakeSession *match_making_types.MatchmakeSession) {
+			matchmakeSession.SystemPasswordEnabled = types.NewPrimitiveBool(true)
+		}, criteria: excludeUserPasswordSet, expected: true},
+	})
+}
+
+func TestCompareSearchCriteriaFiltersExcludeSystemPasswordSet(t *testing.T) {
+	systemPassword := func(matchmakeSession *match_making_types.MatchmakeSession) {
+		matchmakeSession.SystemPasswordEnabled = types.NewPrimitiveBool(true)
+	}
+
+	excludeSystemPasswordSet := func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+		criteria.ExcludeSystemPasswordSet = types.NewPrimitiveBool(true)
+	}
+
+	runSearchCriteriaFilterTests(t, []searchCriteriaFilterTest{
+		{name: "system password without ExcludeSystemPasswordSet", session: systemPassword, expected: true},
+		{name: "no system password", criteria: excludeSystemPasswordSet, expected: true},
+		{name: "system password", session: systemPassword, criteria: excludeSystemPasswordSet, expected: false},
+		{name: "user password", session: func(matchmakeSession *match_making_types.MatchmakeSession) {
+			matchmakeSession.UserPasswordEnabled = types.NewPrimitiveBool(true)
+		}, criteria: excludeSystemPasswordSet, expected: true},
+	})
+}
+
+func TestCompareSearchCriteriaFiltersCodeWord(t *testing.T) {
+	sessionCodeWord := func(codeWord string) func(matchmakeSession *match_making_types.MatchmakeSession) {
+		return func(matchmakeSession *match_making_types.MatchmakeSession) {
+			matchmakeSession.CodeWord = types.NewString(codeWord)
+		}
+	}
+
+	criteriaCodeWord := func(codeWord string) func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+		return func(criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+			criteria.CodeWord = types.NewString(codeWord)
+		}
+	}
+
+	runSearchCriteriaFilterTests(t, []searchCriteriaFilterTest{
+		{name: "no code word", expected: true},
+		{name: "matching code word", session: sessionCodeWord("secret"), criteria: criteriaCodeWord("secret"), expected: true},
+		{name: "different code word", session: sessionCodeWord("secret"), criteria: criteriaCodeWord("other"), expected: false},
+		{name: "session with code word not searched for", session: sessionCodeWord("secret"), expected: false},
+		{name: "code word searched on session without one", criteria: criteriaCodeWord("secret"), expected: false},
+	})
+}
+
+// newTestSearchedSession creates a session hosted by a new connection, with a single attribute
+func newTestSearchedSession(t *testing.T, mm *MatchmakingManager, hostPID uint64, gameMode uint32, attribute uint32, progressScore uint8) *CommonMatchmakeSession {
+	host := newTestEndpointConnection(mm, uint32(hostPID), hostPID)
+
+	matchmakeSession := newTestMatchmakeSession(4)
+	matchmakeSession.GameMode = types.NewPrimitiveU32(gameMode)
+	matchmakeSession.Attributes = types.NewList[*types.PrimitiveU32]()
+	matchmakeSession.Attributes.Type = types.NewPrimitiveU32(0)
+	matchmakeSession.Attributes.Append(types.NewPrimitiveU32(attribute))
+	matchmakeSession.ProgressScore = types.NewPrimitiveU8(progressScore)
+
+	session, errCode := mm.CreateSessionByMatchmakeSession(matchmakeSession, matchmakeSession.Copy().(*match_making_types.MatchmakeSession), host.PID())
+	if errCode != nil {
+		t.Fatal(errCode)
+	}
+
+	session.ConnectionIDs.Add(host.ID)
+
+	return session
+}
+
+func TestFindSessionsBySearchCriteriasSelectionMethod(t *testing.T) {
+	mm := newTestMatchmakingManager()
+	searcher := newTestEndpointConnection(mm, 1, 1000)
+
+	// * Created in gathering ID order, which is the order the sessions are found in
+	sessions := map[string]*CommonMatchmakeSession{
+		"A": newTestSearchedSession(t, mm, 100, 1, 10, 100),
+		"B": newTestSearchedSession(t, mm, 200, 1, 50, 0),
+		"C": newTestSearchedSession(t, mm, 300, 1, 90, 50),
+	}
+
+	// * Not found by the searches, as it is on another game mode
+	referSession := newTestSearchedSession(t, mm, 400, 2, 80, 40)
+
+	tests := []struct {
+		name            string
+		selectionMethod uint32
+		referGID        uint32
+		attribute       string
+		expected        string
+	}{
+		{name: "Random keeps the order", selectionMethod: MatchmakeSelectionMethods.Random, expected: "ABC"},
+		{name: "NearestNeighbor to the middle of the searched range", selectionMethod: MatchmakeSelectionMethods.NearestNeighbor, attribute: "0,144", expected: "CBA"},
+		{name: "NearestNeighbor to the referred gathering", selectionMethod: MatchmakeSelectionMethods.NearestNeighbor, referGID: referSession.GameMatchmakeSession.ID.Value, expected: "CBA"},
+		{name: "BroadenRange keeps the order within a range", selectionMethod: MatchmakeSelectionMethods.BroadenRange, attribute: "0,144", expected: "BCA"},
+		{name: "ProgressScore without referred gathering", selectionMethod: MatchmakeSelectionMethods.ProgressScore, expected: "BCA"},
+		{name: "ProgressScore to the referred gathering", selectionMethod: MatchmakeSelectionMethods.ProgressScore, referGID: referSession.GameMatchmakeSession.ID.Value, expected: "CBA"},
+		{name: "ProgressScore to an unknown gathering", selectionMethod: MatchmakeSelectionMethods.ProgressScore, referGID: 0xFFFF, expected: "BCA"},
+		{name: "ScoreBased without referred gathering", selectionMethod: MatchmakeSelectionMethods.ScoreBased, expected: "ABC"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			criteria := match_making_types.NewMatchmakeSessionSearchCriteria()
+			criteria.GameMode = types.NewString("1")
+			criteria.Attribs = types.NewList[*types.String]()
+			criteria.Attribs.Type = types.NewString("")
+			criteria.Attribs.Append(types.NewString(test.attribute))
+			criteria.SelectionMethod = types.NewPrimitiveU32(test.selectionMethod)
+			criteria.ReferGID = types.NewPrimitiveU32(test.referGID)
+
+			found := mm.FindSessionsByMatchmakeSessionSearchCriterias(searcher, []*match_making_types.MatchmakeSessionSearchCriteria{criteria}, nil)
+
+			var order string
+			for _, session := range found {
+				for name, candidate := range sessions {
+					if session == candidate {
+						order += name
+					}
+				}
+			}
+
+			if order != test.expected || len(found) != len(test.expected) {
+				t.Errorf("Found the sessions in order %q, expected %q", order, test.expected)
+			}
+		})
+	}
+}
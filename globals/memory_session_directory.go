@@ -0,0 +1,148 @@
+package common_globals
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// MemorySessionDirectory is a SessionDirectory shared by the instances living in the same process
+type MemorySessionDirectory struct {
+	mutex     sync.RWMutex
+	sessions  map[string]map[uint32]*DirectorySession // * Keyed by instance ID, then by gathering ID
+	instances map[string]DirectoryInstance
+}
+
+// RegisterInstance implements SessionDirectory
+func (msd *MemorySessionDirectory) RegisterInstance(instanceID string, instance DirectoryInstance) error {
+	msd.mutex.Lock()
+	defer msd.mutex.Unlock()
+
+	if _, ok := msd.instances[instanceID]; ok {
+		return fmt.Errorf("Instance %s is already registered", instanceID)
+	}
+
+	msd.instances[instanceID] = instance
+	msd.sessions[instanceID] = make(map[uint32]*DirectorySession)
+
+	return nil
+}
+
+// UnregisterInstance implements SessionDirectory
+func (msd *MemorySessionDirectory) UnregisterInstance(instanceID string) error {
+	msd.mutex.Lock()
+	delete(msd.instances, instanceID)
+	delete(msd.sessions, instanceID)
+
+	instances := make([]DirectoryInstance, 0, len(msd.instances))
+	for _, instance := range msd.instances {
+		instances = append(instances, instance)
+	}
+	msd.mutex.Unlock()
+
+	for _, instance := range instances {
+		instance.InstanceRemoved(instanceID)
+	}
+
+	return nil
+}
+
+// Publish implements SessionDirectory
+func (msd *MemorySessionDirectory) Publish(session *DirectorySession) error {
+	msd.mutex.Lock()
+	defer msd.mutex.Unlock()
+
+	sessions, ok := msd.sessions[session.InstanceID]
+	if !ok {
+		return fmt.Errorf("Instance %s is not registered", session.InstanceID)
+	}
+
+	for instanceID, otherSessions := range msd.sessions {
+		if _, ok := otherSessions[session.GatheringID]; ok && instanceID != session.InstanceID {
+			return fmt.Errorf("GID %d is already published by instance %s", session.GatheringID, instanceID)
+		}
+	}
+
+	sessions[session.GatheringID] = session
+
+	return nil
+}
+
+// Withdraw implements SessionDirectory
+func (msd *MemorySessionDirectory) Withdraw(instanceID string, gatheringID uint32) error {
+	msd.mutex.Lock()
+	defer msd.mutex.Unlock()
+
+	delete(msd.sessions[instanceID], gatheringID)
+
+	return nil
+}
+
+// List implements SessionDirectory
+func (msd *MemorySessionDirectory) List() ([]*DirectorySession, error) {
+	msd.mutex.RLock()
+	defer msd.mutex.RUnlock()
+
+	list := make([]*DirectorySession, 0)
+	for _, sessions := range msd.sessions {
+		for _, session := range sessions {
+			list = append(list, session)
+		}
+	}
+
+	return list, nil
+}
+
+// Join implements SessionDirectory
+func (msd *MemorySessionDirectory) Join(fromInstanceID string, instanceID string, gatheringID uint32, pid *types.PID, message string, options SessionJoinOptions) (*match_making_types.MatchmakeSession, *nex.Error) {
+	msd.mutex.RLock()
+	instance, ok := msd.instances[instanceID]
+	msd.mutex.RUnlock()
+
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	return instance.JoinFromRemote(fromInstanceID, gatheringID, pid, message, options)
+}
+
+// Leave implements SessionDirectory
+func (msd *MemorySessionDirectory) Leave(instanceID string, gatheringID uint32, pid *types.PID, gracefully bool) error {
+	msd.mutex.RLock()
+	instance, ok := msd.instances[instanceID]
+	msd.mutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("Instance %s is not registered", instanceID)
+	}
+
+	instance.LeaveFromRemote(gatheringID, pid, gracefully)
+
+	return nil
+}
+
+// Unregistered implements SessionDirectory
+func (msd *MemorySessionDirectory) Unregistered(instanceID string, gatheringID uint32, pid *types.PID) error {
+	msd.mutex.RLock()
+	instance, ok := msd.instances[instanceID]
+	msd.mutex.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("Instance %s is not registered", instanceID)
+	}
+
+	instance.UnregisteredFromRemote(gatheringID, pid)
+
+	return nil
+}
+
+// NewMemorySessionDirectory returns a new empty MemorySessionDirectory
+func NewMemorySessionDirectory() *MemorySessionDirectory {
+	return &MemorySessionDirectory{
+		sessions:  make(map[string]map[uint32]*DirectorySession),
+		instances: make(map[string]DirectoryInstance),
+	}
+}
@@ -0,0 +1,227 @@
+package common_globals
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+)
+
+// MetricsRecorder receives the metrics collected by the common protocols.
+// It can be implemented on top of any metrics library, such as a Prometheus exporter
+type MetricsRecorder interface {
+	// ObserveHandler is called after every handled request. errCode is nil if the request succeeded.
+	// Call counts, latency histograms and result code breakdowns can be built from it
+	ObserveHandler(protocolID uint16, methodID uint32, errCode *nex.Error, duration time.Duration)
+
+	// ObserveMatchmakingStats is called periodically by MatchmakingManager.StartMetricsReporting
+	ObserveMatchmakingStats(stats MatchmakingStats)
+}
+
+// MatchmakingStats is a snapshot of the state of the sessions of a MatchmakingManager
+type MatchmakingStats struct {
+	ActiveGatherings int
+	Participants     int
+	AverageFill      float64 // * Average of the participant count over the maximum participants of every gathering, from 0 to 1
+}
+
+// Metrics holds the MetricsRecorder which the metrics of a set of handlers are reported to.
+// The recorder can be changed while the handlers are running. Metrics are disabled while it isn't set
+type Metrics struct {
+	recorder atomic.Pointer[MetricsRecorder]
+}
+
+// SetRecorder sets the MetricsRecorder receiving the metrics. Passing nil disables them
+func (m *Metrics) SetRecorder(recorder MetricsRecorder) {
+	if recorder == nil {
+		m.recorder.Store(nil)
+		return
+	}
+
+	m.recorder.Store(&recorder)
+}
+
+// Recorder returns the current MetricsRecorder, or nil if the metrics are disabled
+func (m *Metrics) Recorder() MetricsRecorder {
+	recorder := m.recorder.Load()
+	if recorder == nil {
+		return nil
+	}
+
+	return *recorder
+}
+
+// NewMetrics returns a new Metrics without a recorder
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+// observeHandler reports a handled request to the recorder
+func observeHandler(recorder MetricsRecorder, packet nex.PacketInterface, errCode *nex.Error, start time.Time) {
+	request := packet.RMCMessage()
+
+	recorder.ObserveHandler(request.ProtocolID, request.MethodID, errCode, time.Since(start))
+}
+
+// MeasureHandler0 wraps a handler without parameters so that its calls are reported to the recorder of metrics
+func MeasureHandler0(metrics *Metrics, handler func(err error, packet nex.PacketInterface, callID uint32) (*nex.RMCMessage, *nex.Error)) func(err error, packet nex.PacketInterface, callID uint32) (*nex.RMCMessage, *nex.Error) {
+	return func(err error, packet nex.PacketInterface, callID uint32) (*nex.RMCMessage, *nex.Error) {
+		recorder := metrics.Recorder()
+		if recorder == nil {
+			return handler(err, packet, callID)
+		}
+
+		start := time.Now()
+		rmcMessage, errCode := handler(err, packet, callID)
+		observeHandler(recorder, packet, errCode, start)
+
+		return rmcMessage, errCode
+	}
+}
+
+// MeasureHandler1 wraps a handler with 1 parameter so that its calls are reported to the recorder of metrics
+func MeasureHandler1[A any](metrics *Metrics, handler func(err error, packet nex.PacketInterface, callID uint32, a A) (*nex.RMCMessage, *nex.Error)) func(err error, packet nex.PacketInterface, callID uint32, a A) (*nex.RMCMessage, *nex.Error) {
+	return func(err error, packet nex.PacketInterface, callID uint32, a A) (*nex.RMCMessage, *nex.Error) {
+		recorder := metrics.Recorder()
+		if recorder == nil {
+			return handler(err, packet, callID, a)
+		}
+
+		start := time.Now()
+		rmcMessage, errCode := handler(err, packet, callID, a)
+		observeHandler(recorder, packet, errCode, start)
+
+		return rmcMessage, errCode
+	}
+}
+
+// MeasureHandler2 wraps a handler with 2 parameters so that its calls are reported to the recorder of metrics
+func MeasureHandler2[A, B any](metrics *Metrics, handler func(err error, packet nex.PacketInterface, callID uint32, a A, b B) (*nex.RMCMessage, *nex.Error)) func(err error, packet nex.PacketInterface, callID uint32, a A, b B) (*nex.RMCMessage, *nex.Error) {
+	return func(err error, packet nex.PacketInterface, callID uint32, a A, b B) (*nex.RMCMessage, *nex.Error) {
+		recorder := metrics.Recorder()
+		if recorder == nil {
+			return handler(err, packet, callID, a, b)
+		}
+
+		start := time.Now()
+		rmcMessage, errCode := handler(err, packet, callID, a, b)
+		observeHandler(recorder, packet, errCode, start)
+
+		return rmcMessage, errCode
+	}
+}
+
+// MeasureHandler3 wraps a handler with 3 parameters so that its calls are reported to the recorder of metrics
+func MeasureHandler3[A, B, C any](metrics *Metrics, handler func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C) (*nex.RMCMessage, *nex.Error)) func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C) (*nex.RMCMessage, *nex.Error) {
+	return func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C) (*nex.RMCMessage, *nex.Error) {
+		recorder := metrics.Recorder()
+		if recorder == nil {
+			return handler(err, packet, callID, a, b, c)
+		}
+
+		start := time.Now()
+		rmcMessage, errCode := handler(err, packet, callID, a, b, c)
+		observeHandler(recorder, packet, errCode, start)
+
+		return rmcMessage, errCode
+	}
+}
+
+// MeasureHandler4 wraps a handler with 4 parameters so that its calls are reported to the recorder of metrics
+func MeasureHandler4[A, B, C, D any](metrics *Metrics, handler func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C, d D) (*nex.RMCMessage, *nex.Error)) func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C, d D) (*nex.RMCMessage, *nex.Error) {
+	return func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C, d D) (*nex.RMCMessage, *nex.Error) {
+		recorder := metrics.Recorder()
+		if recorder == nil {
+			return handler(err, packet, callID, a, b, c, d)
+		}
+
+		start := time.Now()
+		rmcMessage, errCode := handler(err, packet, callID, a, b, c, d)
+		observeHandler(recorder, packet, errCode, start)
+
+		return rmcMessage, errCode
+	}
+}
+
+// MeasureHandler5 wraps a handler with 5 parameters so that its calls are reported to the recorder of metrics
+func MeasureHandler5[A, B, C, D, E any](metrics *Metrics, handler func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C, d D, e E) (*nex.RMCMessage, *nex.Error)) func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C, d D, e E) (*nex.RMCMessage, *nex.Error) {
+	return func(err error, packet nex.PacketInterface, callID uint32, a A, b B, c C, d D, e E) (*nex.RMCMessage, *nex.Error) {
+		recorder := metrics.Recorder()
+		if recorder == nil {
+			return handler(err, packet, callID, a, b, c, d, e)
+		}
+
+		start := time.Now()
+		rmcMessage, errCode := handler(err, packet, callID, a, b, c, d, e)
+		observeHandler(recorder, packet, errCode, start)
+
+		return rmcMessage, errCode
+	}
+}
+
+// MatchmakingStats returns a snapshot of the state of the sessions
+func (mm *MatchmakingManager) MatchmakingStats() MatchmakingStats {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	var stats MatchmakingStats
+	var totalFill float64
+
+	mm.sessions.Each(func(_ uint32, session *CommonMatchmakeSession) bool {
+		// * Count the guests and the players of other instances too, as they use slots like any participant
+		participants := mm.sessionParticipantCountImpl(session)
+
+		stats.ActiveGatherings++
+		stats.Participants += participants
+
+		maximumParticipants := session.GameMatchmakeSession.Gathering.MaximumParticipants.Value
+		if maximumParticipants != 0 {
+			totalFill += float64(participants) / float64(maximumParticipants)
+		}
+
+		return false
+	})
+
+	if stats.ActiveGatherings != 0 {
+		stats.AverageFill = totalFill / float64(stats.ActiveGatherings)
+	}
+
+	return stats
+}
+
+// Metrics returns the Metrics which the matchmaking protocols handlers of the manager are measured with
+func (mm *MatchmakingManager) Metrics() *Metrics {
+	return mm.metrics
+}
+
+// SetMetricsRecorder sets the MetricsRecorder receiving the metrics of the matchmaking protocols handlers,
+// and the reports of StartMetricsReporting. Passing nil disables them
+func (mm *MatchmakingManager) SetMetricsRecorder(recorder MetricsRecorder) {
+	mm.metrics.SetRecorder(recorder)
+}
+
+// StartMetricsReporting reports the MatchmakingStats of the manager to its MetricsRecorder every interval.
+// Nothing is reported while the recorder isn't set.
+// Returns a function which stops the reporting
+func (mm *MatchmakingManager) StartMetricsReporting(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if recorder := mm.metrics.Recorder(); recorder != nil {
+					recorder.ObserveMatchmakingStats(mm.MatchmakingStats())
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
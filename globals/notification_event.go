@@ -0,0 +1,67 @@
+package common_globals
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+)
+
+// NotificationEvent is the NotificationEvent structure sent through ProcessNotificationEvent.
+//
+// Unlike the NotificationEvent type of the Notifications protocol, the parameters are always held as
+// 64 bit values so that they can carry full PIDs. They are only written with their full width
+// when the endpoint runs NEX 4.0.0 or later, and are truncated to 32 bits otherwise
+type NotificationEvent struct {
+	types.Structure
+	PIDSource *types.PID
+	Type      *types.PrimitiveU32
+	Param1    *types.PrimitiveU64
+	Param2    *types.PrimitiveU64
+	StrParam  *types.String
+	Param3    *types.PrimitiveU64
+	MapParam  *types.Map[*types.String, *types.Variant] // * NEX 4.0.0+
+}
+
+// WriteTo writes the NotificationEvent to the given stream, using the parameter width of its library version
+func (ne *NotificationEvent) WriteTo(stream *nex.ByteStreamOut) {
+	contentStream := nex.NewByteStreamOut(stream.LibraryVersions, stream.Settings)
+
+	wideParams := stream.LibraryVersions.Main.GreaterOrEqual("4.0.0")
+
+	writeParam := func(param *types.PrimitiveU64) {
+		if wideParams {
+			param.WriteTo(contentStream)
+		} else {
+			types.NewPrimitiveU32(uint32(param.Value)).WriteTo(contentStream)
+		}
+	}
+
+	ne.PIDSource.WriteTo(contentStream)
+	ne.Type.WriteTo(contentStream)
+	writeParam(ne.Param1)
+	writeParam(ne.Param2)
+	ne.StrParam.WriteTo(contentStream)
+	writeParam(ne.Param3)
+
+	if wideParams {
+		ne.MapParam.WriteTo(contentStream)
+	}
+
+	content := contentStream.Bytes()
+
+	ne.WriteHeaderTo(stream, uint32(len(content)))
+
+	stream.Write(content)
+}
+
+// NewNotificationEvent returns a new NotificationEvent
+func NewNotificationEvent() *NotificationEvent {
+	return &NotificationEvent{
+		PIDSource: types.NewPID(0),
+		Type:      types.NewPrimitiveU32(0),
+		Param1:    types.NewPrimitiveU64(0),
+		Param2:    types.NewPrimitiveU64(0),
+		StrParam:  types.NewString(""),
+		Param3:    types.NewPrimitiveU64(0),
+		MapParam:  types.NewMap[*types.String, *types.Variant](),
+	}
+}
@@ -0,0 +1,157 @@
+package common_globals
+
+import (
+	"fmt"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/constants"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
+)
+
+// NotificationDeliveryFailure describes a recipient which a notification could not be delivered to
+type NotificationDeliveryFailure struct {
+	ConnectionID uint32
+	PID          *types.PID // * nil if the connection could not be found
+	Reason       error
+}
+
+// Error returns a description of the delivery failure
+func (ndf *NotificationDeliveryFailure) Error() string {
+	if ndf.PID == nil {
+		return fmt.Sprintf("Failed to deliver notification to connection %d: %s", ndf.ConnectionID, ndf.Reason.Error())
+	}
+
+	return fmt.Sprintf("Failed to deliver notification to connection %d (PID %d): %s", ndf.ConnectionID, ndf.PID.Value(), ndf.Reason.Error())
+}
+
+func sendPayload(endpoint *nex.PRUDPEndPoint, target *nex.PRUDPConnection, payload []byte) error {
+	server := endpoint.Server
+
+	var messagePacket nex.PRUDPPacketInterface
+	var err error
+
+	if target.DefaultPRUDPVersion == 0 {
+		messagePacket, err = nex.NewPRUDPPacketV0(server, target, nil)
+	} else {
+		messagePacket, err = nex.NewPRUDPPacketV1(server, target, nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	messagePacket.SetType(constants.DataPacket)
+	messagePacket.AddFlag(constants.PacketFlagNeedsAck)
+	messagePacket.AddFlag(constants.PacketFlagReliable)
+	messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
+	messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
+	messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
+	messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
+	messagePacket.SetPayload(payload)
+
+	server.Send(messagePacket)
+
+	return nil
+}
+
+// SendRMCRequest sends an RMC request to the given connection as a reliable data packet
+func SendRMCRequest(endpoint *nex.PRUDPEndPoint, target *nex.PRUDPConnection, rmcRequest *nex.RMCMessage) error {
+	return sendPayload(endpoint, target, rmcRequest.Bytes())
+}
+
+// newNotificationRequest builds a ProcessNotificationEvent RMC request containing the given event
+func (mm *MatchmakingManager) newNotificationRequest(event *NotificationEvent) *nex.RMCMessage {
+	stream := nex.NewByteStreamOut(mm.Endpoint.LibraryVersions(), mm.Endpoint.ByteStreamSettings())
+
+	event.WriteTo(stream)
+
+	rmcRequest := nex.NewRMCRequest(mm.Endpoint)
+	rmcRequest.ProtocolID = notifications.ProtocolID
+	rmcRequest.CallID = mm.CurrentMatchmakingCallID.Next()
+	rmcRequest.MethodID = notifications.MethodProcessNotificationEvent
+	rmcRequest.Parameters = stream.Bytes()
+
+	return rmcRequest
+}
+
+// SendNotificationEvent sends a NotificationEvent to the given connection
+func (mm *MatchmakingManager) SendNotificationEvent(target *nex.PRUDPConnection, event *NotificationEvent) error {
+	return SendRMCRequest(mm.Endpoint, target, mm.newNotificationRequest(event))
+}
+
+// SendNotificationEventToPID sends a NotificationEvent to the connection of the given PID
+func (mm *MatchmakingManager) SendNotificationEventToPID(pid *types.PID, event *NotificationEvent) error {
+	target := mm.Endpoint.FindConnectionByPID(pid.Value())
+	if target == nil {
+		return fmt.Errorf("Connection for PID %d not found", pid.Value())
+	}
+
+	return mm.SendNotificationEvent(target, event)
+}
+
+// SendNotificationEventToConnectionIDs sends the same NotificationEvent to every given connection ID.
+// Returns the recipients which the notification could not be delivered to
+func (mm *MatchmakingManager) SendNotificationEventToConnectionIDs(connectionIDs []uint32, event *NotificationEvent) []*NotificationDeliveryFailure {
+	rmcRequestBytes := mm.newNotificationRequest(event).Bytes()
+	failures := make([]*NotificationDeliveryFailure, 0)
+
+	for _, connectionID := range connectionIDs {
+		target := mm.Endpoint.FindConnectionByID(connectionID)
+		if target == nil {
+			failures = append(failures, &NotificationDeliveryFailure{
+				ConnectionID: connectionID,
+				Reason:       fmt.Errorf("Connection not found"),
+			})
+
+			continue
+		}
+
+		err := sendPayload(mm.Endpoint, target, rmcRequestBytes)
+		if err != nil {
+			failures = append(failures, &NotificationDeliveryFailure{
+				ConnectionID: connectionID,
+				PID:          target.PID(),
+				Reason:       err,
+			})
+		}
+	}
+
+	return failures
+}
+
+// SendNotificationEventToGathering sends a NotificationEvent to every participant and spectator of a gathering.
+// Returns the members which the notification could not be delivered to
+func (mm *MatchmakingManager) SendNotificationEventToGathering(gatheringID uint32, event *NotificationEvent) ([]*NotificationDeliveryFailure, *nex.Error) {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	session, ok := mm.sessions.Get(gatheringID)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	return mm.sendNotificationEventToSessionImpl(session, event), nil
+}
+
+func (mm *MatchmakingManager) sendNotificationEventToSessionImpl(session *CommonMatchmakeSession, event *NotificationEvent) []*NotificationDeliveryFailure {
+	connectionIDs := make([]uint32, 0, session.ConnectionIDs.Size()+session.SpectatorConnectionIDs.Size())
+	session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		connectionIDs = append(connectionIDs, connectionID)
+		return false
+	})
+
+	session.SpectatorConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		connectionIDs = append(connectionIDs, connectionID)
+		return false
+	})
+
+	return mm.SendNotificationEventToConnectionIDs(connectionIDs, event)
+}
+
+// logNotificationDeliveryFailures logs every notification that couldn't be delivered
+func logNotificationDeliveryFailures(failures []*NotificationDeliveryFailure) {
+	for _, failure := range failures {
+		Logger.Warning(failure.Error())
+	}
+}
@@ -0,0 +1,117 @@
+package common_globals
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+)
+
+// ParticipationCheck holds the data given to a ParticipationPolicy
+type ParticipationCheck struct {
+	Manager        *MatchmakingManager
+	PID            *types.PID           // * Player taking part in the session
+	Connection     *nex.PRUDPConnection // * Connection of PID. Nil if the player is connected to another instance
+	Session        *CommonMatchmakeSession
+	PolicyArgument uint32
+	friendPIDs     *[]*types.PID // * Shared between the checks of a search, so the friend list is only requested once
+}
+
+// FriendPIDs returns the friends of the connection, requesting them only once per search.
+// Returns false if there is no GetUserFriendPIDs handler
+func (pc *ParticipationCheck) FriendPIDs() ([]*types.PID, bool) {
+	if pc.Manager.GetUserFriendPIDs == nil {
+		Logger.Warning("Missing GetUserFriendPIDs handler!")
+		return nil, false
+	}
+
+	if *pc.friendPIDs == nil {
+		*pc.friendPIDs = pc.Manager.GetUserFriendPIDs(pc.PID)
+	}
+
+	return *pc.friendPIDs, true
+}
+
+// ParticipationPolicy decides if a connection can take part in a session
+type ParticipationPolicy func(check *ParticipationCheck) bool
+
+type participationPolicyKey struct {
+	policyID uint32
+	argument uint32
+}
+
+// ParticipationPolicyOpen lets anyone take part in the session
+func ParticipationPolicyOpen(check *ParticipationCheck) bool {
+	return true
+}
+
+// ParticipationPolicyFriendsOfOwner only lets the friends of the session owner take part in the session
+func ParticipationPolicyFriendsOfOwner(check *ParticipationCheck) bool {
+	friendPIDs, ok := check.FriendPIDs()
+	if !ok {
+		return false
+	}
+
+	return ContainsPID(friendPIDs, check.Session.GameMatchmakeSession.OwnerPID)
+}
+
+// ParticipationPolicyFriendsOfAnyParticipant only lets the friends of any participant take part in the session
+func ParticipationPolicyFriendsOfAnyParticipant(check *ParticipationCheck) bool {
+	friendPIDs, ok := check.FriendPIDs()
+	if !ok {
+		return false
+	}
+
+	return check.Session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		participant := check.Manager.Endpoint.FindConnectionByID(connectionID)
+		if participant == nil {
+			return false
+		}
+
+		return ContainsPID(friendPIDs, participant.PID())
+	})
+}
+
+// ParticipationPolicyInviteOnly doesn't let anyone take part in the session on their own, only the owner.
+// Invited players can still join the session
+func ParticipationPolicyInviteOnly(check *ParticipationCheck) bool {
+	return check.Session.GameMatchmakeSession.OwnerPID.Equals(check.PID)
+}
+
+// RegisterParticipationPolicy sets the evaluator of a participation policy ID, for any policy argument
+func (mm *MatchmakingManager) RegisterParticipationPolicy(policyID uint32, policy ParticipationPolicy) {
+	mm.participationPolicies.Set(policyID, policy)
+}
+
+// RegisterParticipationPolicyWithArgument sets the evaluator of a participation policy ID with a specific policy argument.
+// It takes precedence over the evaluator registered for the policy ID alone
+func (mm *MatchmakingManager) RegisterParticipationPolicyWithArgument(policyID uint32, argument uint32, policy ParticipationPolicy) {
+	mm.participationPoliciesWithArgument.Set(participationPolicyKey{policyID, argument}, policy)
+}
+
+// canParticipateImpl checks the participation policy of the session. Sessions with an unregistered policy are open to everyone
+func (mm *MatchmakingManager) canParticipateImpl(connection *nex.PRUDPConnection, session *CommonMatchmakeSession, friendPIDs *[]*types.PID) bool {
+	return mm.canPIDParticipateImpl(connection.PID(), connection, session, friendPIDs)
+}
+
+// canPIDParticipateImpl checks the participation policy of the session for a player which may be connected to another instance,
+// in which case connection is nil
+func (mm *MatchmakingManager) canPIDParticipateImpl(pid *types.PID, connection *nex.PRUDPConnection, session *CommonMatchmakeSession, friendPIDs *[]*types.PID) bool {
+	policyID := session.GameMatchmakeSession.ParticipationPolicy.Value
+	argument := session.GameMatchmakeSession.PolicyArgument.Value
+
+	policy, ok := mm.participationPoliciesWithArgument.Get(participationPolicyKey{policyID, argument})
+	if !ok {
+		policy, ok = mm.participationPolicies.Get(policyID)
+		if !ok {
+			return true
+		}
+	}
+
+	return policy(&ParticipationCheck{
+		Manager:        mm,
+		PID:            pid,
+		Connection:     connection,
+		Session:        session,
+		PolicyArgument: argument,
+		friendPIDs:     friendPIDs,
+	})
+}
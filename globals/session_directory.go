@@ -0,0 +1,679 @@
+package common_globals
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
+	"golang.org/x/exp/slices"
+)
+
+// DirectorySession is a session published to a SessionDirectory by the instance which holds it
+type DirectorySession struct {
+	InstanceID             string
+	GatheringID            uint32
+	MatchmakeSession       *match_making_types.MatchmakeSession // * Never holds the session passwords
+	SearchMatchmakeSession *match_making_types.MatchmakeSession // * May be nil
+	HostURLs               *types.List[*types.StationURL]
+}
+
+// DirectoryInstance handles the players of other instances joining and leaving the sessions of an instance,
+// and the sessions of other instances joined by its players being deleted.
+// MatchmakingManager implements it
+type DirectoryInstance interface {
+	JoinFromRemote(fromInstanceID string, gatheringID uint32, pid *types.PID, message string, options SessionJoinOptions) (*match_making_types.MatchmakeSession, *nex.Error)
+	LeaveFromRemote(gatheringID uint32, pid *types.PID, gracefully bool)
+	UnregisteredFromRemote(gatheringID uint32, pid *types.PID)
+	InstanceRemoved(instanceID string)
+}
+
+// remoteParticipant is a participant of a session of this instance which is connected to another instance
+type remoteParticipant struct {
+	instanceID string
+	pid        *types.PID
+	partySize  int // * The player and its guests
+}
+
+// SessionDirectory shares the sessions of several instances of a server, so players on different instances can meet.
+// The sessions returned by the directory must not be modified
+type SessionDirectory interface {
+	// RegisterInstance makes the directory route the joins and leaves of the sessions of the instance to it
+	RegisterInstance(instanceID string, instance DirectoryInstance) error
+
+	// UnregisterInstance stops routing requests to the instance, and tells the other instances with InstanceRemoved
+	UnregisterInstance(instanceID string) error
+
+	// Publish adds a session to the directory, or replaces it if it was already published.
+	// Clients only know sessions by gathering ID, so it fails if another instance published a session with the same one
+	Publish(session *DirectorySession) error
+
+	// Withdraw removes a session from the directory
+	Withdraw(instanceID string, gatheringID uint32) error
+
+	// List returns every published session, including the ones of the calling instance
+	List() ([]*DirectorySession, error)
+
+	// Join adds a player of the instance fromInstanceID to a session through the instance which holds it
+	Join(fromInstanceID string, instanceID string, gatheringID uint32, pid *types.PID, message string, options SessionJoinOptions) (*match_making_types.MatchmakeSession, *nex.Error)
+
+	// Leave removes a player from a session through the instance which holds it
+	Leave(instanceID string, gatheringID uint32, pid *types.PID, gracefully bool) error
+
+	// Unregistered tells the instance of a player that the session it joined was deleted
+	Unregistered(instanceID string, gatheringID uint32, pid *types.PID) error
+}
+
+// SetSessionDirectory publishes the sessions of the manager to the directory, and lets the connections of the
+// endpoint find and join the sessions published by other instances.
+//
+// Every instance must use a different instanceID, and allocate gathering IDs from a disjoint range,
+// such as by starting CurrentGatheringID at a different offset on each instance. Sessions whose gathering ID
+// is already published by another instance are not published.
+//
+// If publishing falls behind the session events, see SessionEventQueueSize, the sessions are published again from scratch.
+// Returns a function which withdraws the sessions of the manager, stops publishing them and unregisters the instance
+func (mm *MatchmakingManager) SetSessionDirectory(directory SessionDirectory, instanceID string) (func(), error) {
+	err := directory.RegisterInstance(instanceID, mm)
+	if err != nil {
+		return nil, err
+	}
+
+	mm.sessionDirectory = directory
+	mm.instanceID = instanceID
+
+	// * Subscribe before listing the sessions, so none of them is missed
+	events, unsubscribe := mm.SubscribeSessionEvents()
+	gatheringIDs := mm.localGatheringIDs()
+
+	stop := make(chan struct{})
+	var once sync.Once
+
+	// * Every update is sent from this goroutine, so a session can't be published again after being withdrawn
+	go func() {
+		mm.resyncDirectorySessions(gatheringIDs)
+
+	publishing:
+		for {
+			select {
+			case event, ok := <-events:
+				if ok {
+					mm.syncDirectorySession(event.GatheringID)
+					continue
+				}
+
+				// * The subscription was dropped for falling behind, so some events were missed
+				Logger.Warning("Session directory publishing fell behind, publishing every session again")
+
+				events, unsubscribe = mm.SubscribeSessionEvents()
+				mm.resyncDirectorySessions(mm.localGatheringIDs())
+			case <-stop:
+				unsubscribe()
+				break publishing
+			}
+		}
+
+		mm.EachSession(func(gatheringID uint32, _ *CommonMatchmakeSession) bool {
+			err := directory.Withdraw(instanceID, gatheringID)
+			if err != nil {
+				Logger.Error(err.Error())
+			}
+
+			return false
+		})
+
+		err := directory.UnregisterInstance(instanceID)
+		if err != nil {
+			Logger.Error(err.Error())
+		}
+
+		// * Nothing is routed between this instance and the others anymore
+		mm.removeRemoteMembers(func(_ string) bool { return true })
+	}()
+
+	return func() {
+		once.Do(func() {
+			close(stop)
+		})
+	}, nil
+}
+
+// localGatheringIDs returns the gathering IDs of the sessions of the manager
+func (mm *MatchmakingManager) localGatheringIDs() []uint32 {
+	gatheringIDs := make([]uint32, 0)
+	mm.EachSession(func(gatheringID uint32, _ *CommonMatchmakeSession) bool {
+		gatheringIDs = append(gatheringIDs, gatheringID)
+		return false
+	})
+
+	return gatheringIDs
+}
+
+// resyncDirectorySessions publishes the given sessions, and withdraws the sessions published by this instance
+// which aren't part of them anymore
+func (mm *MatchmakingManager) resyncDirectorySessions(gatheringIDs []uint32) {
+	directorySessions, err := mm.sessionDirectory.List()
+	if err != nil {
+		Logger.Error(err.Error())
+	}
+
+	for _, directorySession := range directorySessions {
+		if directorySession.InstanceID == mm.instanceID && !slices.Contains(gatheringIDs, directorySession.GatheringID) {
+			mm.syncDirectorySession(directorySession.GatheringID)
+		}
+	}
+
+	for _, gatheringID := range gatheringIDs {
+		mm.syncDirectorySession(gatheringID)
+	}
+}
+
+// newDirectorySessionImpl prepares a session to be published to the directory
+func (mm *MatchmakingManager) newDirectorySessionImpl(session *CommonMatchmakeSession) *DirectorySession {
+	directorySession := &DirectorySession{
+		InstanceID:       mm.instanceID,
+		GatheringID:      session.GameMatchmakeSession.Gathering.ID.Value,
+		MatchmakeSession: session.GameMatchmakeSession.Copy().(*match_making_types.MatchmakeSession),
+		HostURLs:         types.NewList[*types.StationURL](),
+	}
+
+	directorySession.HostURLs.Type = types.NewStationURL("")
+
+	if session.SearchMatchmakeSession != nil {
+		directorySession.SearchMatchmakeSession = session.SearchMatchmakeSession.Copy().(*match_making_types.MatchmakeSession)
+	}
+
+	host := mm.Endpoint.FindConnectionByPID(session.GameMatchmakeSession.Gathering.HostPID.Value())
+	if host == nil {
+		host = mm.Endpoint.FindConnectionByPID(session.GameMatchmakeSession.Gathering.OwnerPID.Value())
+	}
+
+	if host != nil {
+		directorySession.HostURLs = host.StationURLs.Copy().(*types.List[*types.StationURL])
+	}
+
+	return directorySession
+}
+
+// syncDirectorySession publishes the current state of the session to the directory, or withdraws it if it was deleted
+func (mm *MatchmakingManager) syncDirectorySession(gatheringID uint32) {
+	var directorySession *DirectorySession
+
+	mm.sessionsMutex.RLock()
+	session, ok := mm.sessions.Get(gatheringID)
+	if ok {
+		directorySession = mm.newDirectorySessionImpl(session)
+	}
+	mm.sessionsMutex.RUnlock()
+
+	var err error
+	if directorySession != nil {
+		err = mm.sessionDirectory.Publish(directorySession)
+	} else {
+		err = mm.sessionDirectory.Withdraw(mm.instanceID, gatheringID)
+	}
+
+	if err != nil {
+		Logger.Error(err.Error())
+	}
+}
+
+// listRemoteSessions returns the sessions published by the other instances. Returns nil if there is no directory
+func (mm *MatchmakingManager) listRemoteSessions() []*DirectorySession {
+	if mm.sessionDirectory == nil {
+		return nil
+	}
+
+	directorySessions, err := mm.sessionDirectory.List()
+	if err != nil {
+		Logger.Error(err.Error())
+		return nil
+	}
+
+	remoteSessions := make([]*DirectorySession, 0, len(directorySessions))
+	for _, directorySession := range directorySessions {
+		if directorySession.InstanceID != mm.instanceID {
+			remoteSessions = append(remoteSessions, directorySession)
+		}
+	}
+
+	return remoteSessions
+}
+
+// canJoinRemoteSession checks if the connection and its party can automatically join a session of another instance
+func (mm *MatchmakingManager) canJoinRemoteSession(connection *nex.PRUDPConnection, directorySession *DirectorySession, participationCount uint16, friendPIDs *[]*types.PID) bool {
+	matchmakeSession := directorySession.MatchmakeSession
+
+	if !matchmakeSession.OpenParticipation.Value || matchmakeSession.UserPasswordEnabled.Value || matchmakeSession.SystemPasswordEnabled.Value {
+		return false
+	}
+
+	if matchmakeSession.ParticipationCount.Value+uint32(participationCount) > uint32(matchmakeSession.Gathering.MaximumParticipants.Value) {
+		return false
+	}
+
+	// * The participants of remote sessions are unknown, so only the policies which don't need them work
+	return mm.canParticipateImpl(connection, newRemoteCommonMatchmakeSession(directorySession), friendPIDs)
+}
+
+// FindRemoteSession returns the session of another instance with the given gathering ID
+func (mm *MatchmakingManager) FindRemoteSession(gatheringID uint32) (*DirectorySession, bool) {
+	for _, directorySession := range mm.listRemoteSessions() {
+		if directorySession.GatheringID == gatheringID {
+			return directorySession, true
+		}
+	}
+
+	return nil, false
+}
+
+// FindRemoteSessionByMatchmakeSession finds a session of another instance that matches with a MatchmakeSession
+// and has enough free slots for the whole party. Returns nil if there is none
+func (mm *MatchmakingManager) FindRemoteSessionByMatchmakeSession(connection *nex.PRUDPConnection, searchMatchmakeSession *match_making_types.MatchmakeSession, participationCount uint16) *DirectorySession {
+	var friendPIDs []*types.PID
+	for _, directorySession := range mm.listRemoteSessions() {
+		if directorySession.SearchMatchmakeSession == nil || !directorySession.SearchMatchmakeSession.Equals(searchMatchmakeSession) {
+			continue
+		}
+
+		if mm.canJoinRemoteSession(connection, directorySession, participationCount, &friendPIDs) {
+			return directorySession
+		}
+	}
+
+	return nil
+}
+
+// newRemoteCommonMatchmakeSession wraps a session of another instance, so that it can be checked and sorted like the local ones.
+// Its participants are unknown, so its published participation count is held as reserved slots
+func newRemoteCommonMatchmakeSession(directorySession *DirectorySession) *CommonMatchmakeSession {
+	session := &CommonMatchmakeSession{
+		GameMatchmakeSession:   directorySession.MatchmakeSession,
+		SearchMatchmakeSession: directorySession.SearchMatchmakeSession,
+		ConnectionIDs:          nex.NewMutexSlice[uint32](),
+		SpectatorConnectionIDs: nex.NewMutexSlice[uint32](),
+	}
+
+	session.reserveSlots(0, int(directorySession.MatchmakeSession.ParticipationCount.Value))
+
+	return session
+}
+
+// listRemoteCommonMatchmakeSessions returns the sessions published by the other instances, wrapped with newRemoteCommonMatchmakeSession.
+// They must not be modified nor registered on the manager
+func (mm *MatchmakingManager) listRemoteCommonMatchmakeSessions() []*CommonMatchmakeSession {
+	directorySessions := mm.listRemoteSessions()
+
+	sessions := make([]*CommonMatchmakeSession, 0, len(directorySessions))
+	for _, directorySession := range directorySessions {
+		sessions = append(sessions, newRemoteCommonMatchmakeSession(directorySession))
+	}
+
+	return sessions
+}
+
+// JoinRemoteSession adds the connection to a session of another instance.
+// Returns the joined MatchmakeSession, or a NEX error code if the session doesn't exist or can't be joined
+func (mm *MatchmakingManager) JoinRemoteSession(connection *nex.PRUDPConnection, gatheringID uint32, message string, options SessionJoinOptions) (*match_making_types.MatchmakeSession, *nex.Error) {
+	directorySession, ok := mm.FindRemoteSession(gatheringID)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	matchmakeSession, errCode := mm.sessionDirectory.Join(mm.instanceID, directorySession.InstanceID, gatheringID, connection.PID(), message, options)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	mm.remoteMemberships.Set(connection.ID, directorySession)
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Added PID %d to the session of instance %s", gatheringID, connection.PID().Value(), directorySession.InstanceID)
+	}
+
+	return matchmakeSession, nil
+}
+
+// LeaveRemoteSession removes the connection from a session of another instance.
+// Returns false if the connection isn't in that session
+func (mm *MatchmakingManager) LeaveRemoteSession(connection *nex.PRUDPConnection, gatheringID uint32, gracefully bool) bool {
+	directorySession, ok := mm.remoteMemberships.Get(connection.ID)
+	if !ok || directorySession.GatheringID != gatheringID {
+		return false
+	}
+
+	mm.remoteMemberships.Delete(connection.ID)
+
+	err := mm.sessionDirectory.Leave(directorySession.InstanceID, gatheringID, connection.PID(), gracefully)
+	if err != nil {
+		Logger.Error(err.Error())
+	}
+
+	return true
+}
+
+// leaveRemoteSessions removes the connection from any session of another instance
+func (mm *MatchmakingManager) leaveRemoteSessions(connection *nex.PRUDPConnection, gracefully bool) {
+	directorySession, ok := mm.remoteMemberships.Get(connection.ID)
+	if ok {
+		mm.LeaveRemoteSession(connection, directorySession.GatheringID, gracefully)
+	}
+}
+
+// sessionParticipantCountImpl returns the number of participants of the session, including their guests and the ones of other instances
+func (mm *MatchmakingManager) sessionParticipantCountImpl(session *CommonMatchmakeSession) int {
+	participants := session.OccupiedSlots()
+	for _, remoteParticipant := range mm.remoteParticipants[session.GameMatchmakeSession.Gathering.ID.Value] {
+		participants += remoteParticipant.partySize
+	}
+
+	return participants
+}
+
+// findRemoteParticipantImpl returns the index of the player in the participants of other instances of the session, or -1 if it isn't there
+func (mm *MatchmakingManager) findRemoteParticipantImpl(gatheringID uint32, pid *types.PID) int {
+	return slices.IndexFunc(mm.remoteParticipants[gatheringID], func(remoteParticipant *remoteParticipant) bool {
+		return remoteParticipant.pid.Equals(pid)
+	})
+}
+
+// JoinFromRemote adds a player of another instance to a session of this instance, and notifies the session owner.
+// The join is checked like VerifySessionJoin does, with the options given on the other instance.
+// Returns the joined MatchmakeSession, or a NEX error code if the player can't join
+func (mm *MatchmakingManager) JoinFromRemote(fromInstanceID string, gatheringID uint32, pid *types.PID, message string, options SessionJoinOptions) (*match_making_types.MatchmakeSession, *nex.Error) {
+	session, ok := mm.GetSession(gatheringID)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	errCode := mm.runSessionJoinChecks(pid, session)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	// * TOCTOU, just in case
+	session, ok = mm.sessions.Get(gatheringID)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	errCode = mm.verifySessionJoinImpl(pid, nil, session, options)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	if mm.findRemoteParticipantImpl(gatheringID, pid) != -1 {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.AlreadyParticipatedGathering, fmt.Sprintf("PID %d is already in gathering %d", pid.Value(), gatheringID))
+	}
+
+	partySize := options.partySize()
+	if mm.sessionParticipantCountImpl(session)+partySize > int(session.GameMatchmakeSession.Gathering.MaximumParticipants.Value) {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionFull, fmt.Sprintf("Gathering %d is full", gatheringID))
+	}
+
+	mm.remoteParticipants[gatheringID] = append(mm.remoteParticipants[gatheringID], &remoteParticipant{
+		instanceID: fromInstanceID,
+		pid:        pid,
+		partySize:  partySize,
+	})
+	session.GameMatchmakeSession.ParticipationCount.Value = uint32(mm.sessionParticipantCountImpl(session))
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Added remote PID %d", gatheringID, pid.Value())
+	}
+
+	category := notifications.NotificationCategories.Participation
+	subtype := notifications.NotificationSubTypes.Participation.NewParticipant
+
+	oEvent := NewNotificationEvent()
+	oEvent.PIDSource = pid
+	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gatheringID))
+	oEvent.Param2 = types.NewPrimitiveU64(pid.Value())
+	oEvent.StrParam = types.NewString(message)
+	oEvent.Param3 = types.NewPrimitiveU64(uint64(partySize))
+
+	err := mm.SendNotificationEventToPID(session.GameMatchmakeSession.Gathering.OwnerPID, oEvent)
+	if err != nil {
+		Logger.Warning(err.Error())
+	}
+
+	mm.emitPIDSessionEventImpl(SessionEventTypes.PlayerJoined, session, pid, 0, SessionEventReasons.None)
+
+	return session.GameMatchmakeSession.Copy().(*match_making_types.MatchmakeSession), nil
+}
+
+// LeaveFromRemote removes a player of another instance from a session of this instance, and notifies the session owner
+func (mm *MatchmakingManager) LeaveFromRemote(gatheringID uint32, pid *types.PID, gracefully bool) {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	session, ok := mm.sessions.Get(gatheringID)
+	if !ok {
+		return
+	}
+
+	index := mm.findRemoteParticipantImpl(gatheringID, pid)
+	if index == -1 {
+		return
+	}
+
+	mm.removeRemoteParticipantImpl(session, index, gracefully)
+}
+
+// removeRemoteParticipantImpl removes the participant of another instance at the given index from the session, and notifies the session owner
+func (mm *MatchmakingManager) removeRemoteParticipantImpl(session *CommonMatchmakeSession, index int, gracefully bool) {
+	gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+	remoteParticipants := mm.remoteParticipants[gatheringID]
+	pid := remoteParticipants[index].pid
+
+	if len(remoteParticipants) == 1 {
+		delete(mm.remoteParticipants, gatheringID)
+	} else {
+		mm.remoteParticipants[gatheringID] = slices.Delete(remoteParticipants, index, index+1)
+	}
+
+	session.GameMatchmakeSession.ParticipationCount.Value = uint32(mm.sessionParticipantCountImpl(session))
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Removed remote PID %d", gatheringID, pid.Value())
+	}
+
+	category := notifications.NotificationCategories.Participation
+
+	subtype := notifications.NotificationSubTypes.Participation.Ended
+	reason := SessionEventReasons.Graceful
+	if !gracefully {
+		subtype = notifications.NotificationSubTypes.Participation.Disconnected
+		reason = SessionEventReasons.Disconnect
+	}
+
+	oEvent := NewNotificationEvent()
+	oEvent.PIDSource = pid
+	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gatheringID))
+	oEvent.Param2 = types.NewPrimitiveU64(pid.Value())
+
+	err := mm.SendNotificationEventToPID(session.GameMatchmakeSession.Gathering.OwnerPID, oEvent)
+	if err != nil {
+		Logger.Warning(err.Error())
+	}
+
+	mm.emitPIDSessionEventImpl(SessionEventTypes.PlayerLeft, session, pid, 0, reason)
+}
+
+// notifyRemoteParticipantsUnregistered tells the instances of the given participants that their session was deleted.
+// It goes through the network, so it must not be called with the sessions mutex held
+func (mm *MatchmakingManager) notifyRemoteParticipantsUnregistered(gatheringID uint32, remoteParticipants []*remoteParticipant) {
+	for _, remoteParticipant := range remoteParticipants {
+		err := mm.sessionDirectory.Unregistered(remoteParticipant.instanceID, gatheringID, remoteParticipant.pid)
+		if err != nil {
+			Logger.Error(err.Error())
+		}
+	}
+}
+
+// UnregisteredFromRemote removes a player of this instance from a session of another instance which was deleted,
+// and notifies the player like for the local sessions
+func (mm *MatchmakingManager) UnregisteredFromRemote(gatheringID uint32, pid *types.PID) {
+	connection := mm.Endpoint.FindConnectionByPID(pid.Value())
+	if connection == nil {
+		return
+	}
+
+	var directorySession *DirectorySession
+	mm.remoteMemberships.DeleteIf(func(connectionID uint32, remoteMembership *DirectorySession) bool {
+		if connectionID != connection.ID || remoteMembership.GatheringID != gatheringID {
+			return false
+		}
+
+		directorySession = remoteMembership
+		return true
+	})
+
+	if directorySession != nil {
+		mm.notifyRemoteMembershipUnregistered(connection.ID, directorySession)
+	}
+}
+
+// notifyRemoteMembershipUnregistered sends a GatheringUnregistered notification about a session of another instance
+func (mm *MatchmakingManager) notifyRemoteMembershipUnregistered(connectionID uint32, directorySession *DirectorySession) {
+	connection := mm.Endpoint.FindConnectionByID(connectionID)
+	if connection == nil {
+		return
+	}
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Deleted on instance %s, removed PID %d", directorySession.GatheringID, directorySession.InstanceID, connection.PID().Value())
+	}
+
+	category := notifications.NotificationCategories.GatheringUnregistered
+	subtype := notifications.NotificationSubTypes.GatheringUnregistered.None
+
+	oEvent := NewNotificationEvent()
+	oEvent.PIDSource = directorySession.MatchmakeSession.Gathering.OwnerPID
+	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(directorySession.GatheringID))
+
+	err := mm.SendNotificationEvent(connection, oEvent)
+	if err != nil {
+		Logger.Warning(err.Error())
+	}
+}
+
+// InstanceRemoved drops the players of an instance which left the directory from the sessions of this instance,
+// and the players of this instance from the sessions of the removed instance
+func (mm *MatchmakingManager) InstanceRemoved(instanceID string) {
+	mm.removeRemoteMembers(func(remoteInstanceID string) bool {
+		return remoteInstanceID == instanceID
+	})
+}
+
+// removeRemoteMembers drops every remote participant and remote membership shared with the instances matching the predicate.
+// They can't leave through the directory anymore, so they would stay in the sessions otherwise
+func (mm *MatchmakingManager) removeRemoteMembers(predicate func(instanceID string) bool) {
+	mm.sessionsMutex.Lock()
+	for gatheringID := range mm.remoteParticipants {
+		session, ok := mm.sessions.Get(gatheringID)
+		if !ok {
+			delete(mm.remoteParticipants, gatheringID)
+			continue
+		}
+
+		// * Iterate backwards, as the participants are removed from the slice
+		for index := len(mm.remoteParticipants[gatheringID]) - 1; index >= 0; index-- {
+			if predicate(mm.remoteParticipants[gatheringID][index].instanceID) {
+				mm.removeRemoteParticipantImpl(session, index, false)
+			}
+		}
+	}
+	mm.sessionsMutex.Unlock()
+
+	removedMemberships := make(map[uint32]*DirectorySession)
+	mm.remoteMemberships.DeleteIf(func(connectionID uint32, directorySession *DirectorySession) bool {
+		if !predicate(directorySession.InstanceID) {
+			return false
+		}
+
+		removedMemberships[connectionID] = directorySession
+		return true
+	})
+
+	for connectionID, directorySession := range removedMemberships {
+		mm.notifyRemoteMembershipUnregistered(connectionID, directorySession)
+	}
+}
+
+// encodeDirectorySession serializes a DirectorySession, for directories which share the sessions over the network
+func encodeDirectorySession(directorySession *DirectorySession, libraryVersions *nex.LibraryVersions) []byte {
+	stream := nex.NewByteStreamOut(libraryVersions, snapshotStreamSettings())
+
+	types.NewString(directorySession.InstanceID).WriteTo(stream)
+	stream.WritePrimitiveUInt32LE(directorySession.GatheringID)
+	directorySession.MatchmakeSession.WriteTo(stream)
+
+	stream.WritePrimitiveBool(directorySession.SearchMatchmakeSession != nil)
+	if directorySession.SearchMatchmakeSession != nil {
+		directorySession.SearchMatchmakeSession.WriteTo(stream)
+	}
+
+	directorySession.HostURLs.WriteTo(stream)
+
+	return stream.Bytes()
+}
+
+// decodeDirectorySession deserializes a DirectorySession serialized with encodeDirectorySession
+func decodeDirectorySession(data []byte, libraryVersions *nex.LibraryVersions) (*DirectorySession, error) {
+	stream := nex.NewByteStreamIn(data, libraryVersions, snapshotStreamSettings())
+
+	directorySession := &DirectorySession{
+		MatchmakeSession: match_making_types.NewMatchmakeSession(),
+		HostURLs:         types.NewList[*types.StationURL](),
+	}
+
+	directorySession.HostURLs.Type = types.NewStationURL("")
+
+	instanceID := types.NewString("")
+	err := instanceID.ExtractFrom(stream)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read directory session instance ID. %s", err.Error())
+	}
+
+	directorySession.InstanceID = instanceID.Value
+
+	directorySession.GatheringID, err = stream.ReadPrimitiveUInt32LE()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read directory session gathering ID. %s", err.Error())
+	}
+
+	err = directorySession.MatchmakeSession.ExtractFrom(stream)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read directory session of GID %d. %s", directorySession.GatheringID, err.Error())
+	}
+
+	hasSearchMatchmakeSession, err := stream.ReadPrimitiveBool()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read directory search session flag of GID %d. %s", directorySession.GatheringID, err.Error())
+	}
+
+	if hasSearchMatchmakeSession {
+		directorySession.SearchMatchmakeSession = match_making_types.NewMatchmakeSession()
+		err = directorySession.SearchMatchmakeSession.ExtractFrom(stream)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read directory search session of GID %d. %s", directorySession.GatheringID, err.Error())
+		}
+	}
+
+	err = directorySession.HostURLs.ExtractFrom(stream)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read directory host URLs of GID %d. %s", directorySession.GatheringID, err.Error())
+	}
+
+	return directorySession, nil
+}
@@ -0,0 +1,555 @@
+package common_globals
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	"golang.org/x/exp/slices"
+)
+
+// testDirectories returns the directories used by two instances sharing their sessions
+type testDirectories func(t *testing.T, libraryVersions *nex.LibraryVersions) (SessionDirectory, SessionDirectory)
+
+var sessionDirectoryImplementations = []struct {
+	name        string
+	directories testDirectories
+}{
+	{
+		name: "memory",
+		directories: func(t *testing.T, _ *nex.LibraryVersions) (SessionDirectory, SessionDirectory) {
+			directory := NewMemorySessionDirectory()
+			return directory, directory
+		},
+	},
+	{
+		name: "tcp",
+		directories: func(t *testing.T, libraryVersions *nex.LibraryVersions) (SessionDirectory, SessionDirectory) {
+			server := NewTCPSessionDirectoryServer()
+			err := server.Listen("127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() { server.Close() })
+
+			first, err := NewTCPSessionDirectory(server.Addr().String(), libraryVersions)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			second, err := NewTCPSessionDirectory(server.Addr().String(), libraryVersions)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() {
+				first.Close()
+				second.Close()
+			})
+
+			return first, second
+		},
+	},
+}
+
+func newTestMatchmakingManager() *MatchmakingManager {
+	server := nex.NewPRUDPServer()
+	server.LibraryVersions.SetDefault(nex.NewLibraryVersion(3, 8, 0))
+
+	endpoint := nex.NewPRUDPEndPoint(1)
+	server.BindPRUDPEndPoint(endpoint)
+
+	return NewMatchmakingManager(endpoint)
+}
+
+// newTestConnection returns a connection of the endpoint of the manager. Only the endpoint can make a connection
+// able to receive packets, so it isn't registered on it and the notifications sent to it fail and are logged
+func newTestConnection(mm *MatchmakingManager, connectionID uint32, pid uint64) *nex.PRUDPConnection {
+	socket := nex.NewSocketConnection(mm.Endpoint.Server, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: int(connectionID)}, nil)
+
+	connection := nex.NewPRUDPConnection(socket)
+	connection.ID = connectionID
+	connection.SetPID(types.NewPID(pid))
+
+	return connection
+}
+
+// recordingDirectoryInstance is a DirectoryInstance which records the sessions its players were removed from
+type recordingDirectoryInstance struct {
+	unregistered chan uint32
+}
+
+func (rdi *recordingDirectoryInstance) JoinFromRemote(_ string, _ uint32, _ *types.PID, _ string, _ SessionJoinOptions) (*match_making_types.MatchmakeSession, *nex.Error) {
+	return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+}
+
+func (rdi *recordingDirectoryInstance) LeaveFromRemote(_ uint32, _ *types.PID, _ bool) {}
+
+func (rdi *recordingDirectoryInstance) UnregisteredFromRemote(gatheringID uint32, _ *types.PID) {
+	rdi.unregistered <- gatheringID
+}
+
+func (rdi *recordingDirectoryInstance) InstanceRemoved(_ string) {}
+
+func newTestMatchmakeSession(maximumParticipants uint16) *match_making_types.MatchmakeSession {
+	matchmakeSession := match_making_types.NewMatchmakeSession()
+	matchmakeSession.Gathering.MaximumParticipants = types.NewPrimitiveU16(maximumParticipants)
+	matchmakeSession.OpenParticipation = types.NewPrimitiveBool(true)
+
+	return matchmakeSession
+}
+
+func newTestDirectorySession(instanceID string, gatheringID uint32) *DirectorySession {
+	directorySession := &DirectorySession{
+		InstanceID:       instanceID,
+		GatheringID:      gatheringID,
+		MatchmakeSession: newTestMatchmakeSession(4),
+		HostURLs:         types.NewList[*types.StationURL](),
+	}
+
+	directorySession.MatchmakeSession.Gathering.ID = types.NewPrimitiveU32(gatheringID)
+	directorySession.HostURLs.Type = types.NewStationURL("")
+
+	return directorySession
+}
+
+// waitFor polls the condition until it is met, and fails the test if it isn't within a second
+func waitFor(t *testing.T, description string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", description)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func listedGatheringIDs(t *testing.T, directory SessionDirectory) map[string][]uint32 {
+	t.Helper()
+
+	directorySessions, err := directory.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gatheringIDs := make(map[string][]uint32)
+	for _, directorySession := range directorySessions {
+		gatheringIDs[directorySession.InstanceID] = append(gatheringIDs[directorySession.InstanceID], directorySession.GatheringID)
+	}
+
+	return gatheringIDs
+}
+
+func TestSessionDirectoryPublishWithdrawList(t *testing.T) {
+	for _, implementation := range sessionDirectoryImplementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			first, second := implementation.directories(t, newTestMatchmakingManager().Endpoint.LibraryVersions())
+
+			err := first.RegisterInstance("first", newTestMatchmakingManager())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = second.RegisterInstance("second", newTestMatchmakingManager())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = first.Publish(newTestDirectorySession("unknown", 1))
+			if err == nil {
+				t.Error("Publishing for an unregistered instance should fail")
+			}
+
+			published := newTestDirectorySession("first", 1)
+			published.MatchmakeSession.GameMode = types.NewPrimitiveU32(7)
+
+			err = first.Publish(published)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = second.Publish(newTestDirectorySession("second", 1001))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = second.Publish(newTestDirectorySession("second", 1))
+			if err == nil {
+				t.Error("Publishing a gathering ID already published by another instance should fail")
+			}
+
+			// * Publishing again replaces the session of the same instance
+			err = first.Publish(published)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// * Every instance sees the sessions of all instances
+			gatheringIDs := listedGatheringIDs(t, second)
+			if len(gatheringIDs["first"]) != 1 || gatheringIDs["first"][0] != 1 || len(gatheringIDs["second"]) != 1 || gatheringIDs["second"][0] != 1001 {
+				t.Fatalf("Unexpected listed sessions %v", gatheringIDs)
+			}
+
+			directorySessions, err := second.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, directorySession := range directorySessions {
+				if directorySession.InstanceID == "first" && directorySession.MatchmakeSession.GameMode.Value != 7 {
+					t.Errorf("Listed session has game mode %d, expected 7", directorySession.MatchmakeSession.GameMode.Value)
+				}
+			}
+
+			err = first.Withdraw("first", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gatheringIDs = listedGatheringIDs(t, first)
+			if len(gatheringIDs["first"]) != 0 || len(gatheringIDs["second"]) != 1 {
+				t.Fatalf("Unexpected listed sessions after withdrawing %v", gatheringIDs)
+			}
+
+			err = second.UnregisterInstance("second")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gatheringIDs = listedGatheringIDs(t, first)
+			if len(gatheringIDs) != 0 {
+				t.Fatalf("Unexpected listed sessions after unregistering %v", gatheringIDs)
+			}
+		})
+	}
+}
+
+func TestSessionDirectoryRegisterInstanceTwice(t *testing.T) {
+	for _, implementation := range sessionDirectoryImplementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			first, second := implementation.directories(t, newTestMatchmakingManager().Endpoint.LibraryVersions())
+
+			err := first.RegisterInstance("instance", newTestMatchmakingManager())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = second.RegisterInstance("instance", newTestMatchmakingManager())
+			if err == nil {
+				t.Error("Registering the same instance ID twice should fail")
+			}
+		})
+	}
+}
+
+// remoteSessionTest holds two managers sharing their sessions, with a session held by the first one
+type remoteSessionTest struct {
+	holder       *MatchmakingManager
+	joiner       *MatchmakingManager
+	session      *CommonMatchmakeSession
+	joinerClient *nex.PRUDPConnection
+	stopJoiner   func()
+}
+
+func newRemoteSessionTest(t *testing.T, directories testDirectories) *remoteSessionTest {
+	holder := newTestMatchmakingManager()
+	joiner := newTestMatchmakingManager()
+	joiner.CurrentGatheringID = nex.NewCounter[uint32](1000)
+
+	holderDirectory, joinerDirectory := directories(t, holder.Endpoint.LibraryVersions())
+
+	host := newTestConnection(holder, 1, 100)
+
+	session, errCode := holder.CreateSessionByMatchmakeSession(newTestMatchmakeSession(4), nil, host.PID())
+	if errCode != nil {
+		t.Fatal(errCode.Error())
+	}
+
+	// * AddPlayersToSession needs a connection made by the endpoint
+	session.ConnectionIDs.Add(host.ID)
+	session.GameMatchmakeSession.ParticipationCount = types.NewPrimitiveU32(1)
+
+	stopHolder, err := holder.SetSessionDirectory(holderDirectory, "holder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(stopHolder)
+
+	stopJoiner, err := joiner.SetSessionDirectory(joinerDirectory, "joiner")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+	waitFor(t, "the session to be published", func() bool {
+		_, ok := joiner.FindRemoteSession(gatheringID)
+		return ok
+	})
+
+	return &remoteSessionTest{
+		holder:       holder,
+		joiner:       joiner,
+		session:      session,
+		joinerClient: newTestConnection(joiner, 1, 200),
+		stopJoiner:   stopJoiner,
+	}
+}
+
+// remoteParticipantCount returns the number of participants of other instances in the session of the holder
+func (rst *remoteSessionTest) remoteParticipantCount() int {
+	rst.holder.sessionsMutex.RLock()
+	defer rst.holder.sessionsMutex.RUnlock()
+
+	return len(rst.holder.remoteParticipants[rst.session.GameMatchmakeSession.Gathering.ID.Value])
+}
+
+// participationCount returns the participation count of the session of the holder
+func (rst *remoteSessionTest) participationCount() uint32 {
+	rst.holder.sessionsMutex.RLock()
+	defer rst.holder.sessionsMutex.RUnlock()
+
+	return rst.session.GameMatchmakeSession.ParticipationCount.Value
+}
+
+func (rst *remoteSessionTest) join(t *testing.T, options SessionJoinOptions) {
+	t.Helper()
+
+	matchmakeSession, errCode := rst.joiner.JoinRemoteSession(rst.joinerClient, rst.session.GameMatchmakeSession.Gathering.ID.Value, "", options)
+	if errCode != nil {
+		t.Fatal(errCode.Error())
+	}
+
+	if matchmakeSession.Gathering.ID.Value != rst.session.GameMatchmakeSession.Gathering.ID.Value {
+		t.Fatalf("Joined gathering %d, expected %d", matchmakeSession.Gathering.ID.Value, rst.session.GameMatchmakeSession.Gathering.ID.Value)
+	}
+}
+
+func TestSessionDirectoryRemoteJoinAndLeave(t *testing.T) {
+	for _, implementation := range sessionDirectoryImplementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			rst := newRemoteSessionTest(t, implementation.directories)
+			defer rst.stopJoiner()
+
+			rst.join(t, SessionJoinOptions{ParticipationCount: 2})
+
+			if rst.remoteParticipantCount() != 1 {
+				t.Fatalf("Session has %d remote participants, expected 1", rst.remoteParticipantCount())
+			}
+
+			// * The host and the party of two
+			if rst.participationCount() != 3 {
+				t.Fatalf("Session has a participation count of %d, expected 3", rst.participationCount())
+			}
+
+			_, errCode := rst.joiner.JoinRemoteSession(rst.joinerClient, rst.session.GameMatchmakeSession.Gathering.ID.Value, "", SessionJoinOptions{})
+			if errCode == nil || errCode.ResultCode != nex.NewError(nex.ResultCodes.RendezVous.AlreadyParticipatedGathering, "").ResultCode {
+				t.Errorf("Joining twice returned %v, expected AlreadyParticipatedGathering", errCode)
+			}
+
+			if !rst.joiner.LeaveRemoteSession(rst.joinerClient, rst.session.GameMatchmakeSession.Gathering.ID.Value, true) {
+				t.Fatal("Leaving the remote session failed")
+			}
+
+			if rst.remoteParticipantCount() != 0 || rst.participationCount() != 1 {
+				t.Fatalf("Session has %d remote participants and a participation count of %d after leaving", rst.remoteParticipantCount(), rst.participationCount())
+			}
+		})
+	}
+}
+
+func TestSessionDirectoryRemoteJoinChecks(t *testing.T) {
+	for _, implementation := range sessionDirectoryImplementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			rst := newRemoteSessionTest(t, implementation.directories)
+			defer rst.stopJoiner()
+
+			gatheringID := rst.session.GameMatchmakeSession.Gathering.ID.Value
+
+			_, errCode := rst.joiner.JoinRemoteSession(rst.joinerClient, gatheringID, "", SessionJoinOptions{ParticipationCount: 4})
+			if errCode == nil || errCode.ResultCode != nex.NewError(nex.ResultCodes.RendezVous.SessionFull, "").ResultCode {
+				t.Errorf("Joining with a party larger than the free slots returned %v, expected SessionFull", errCode)
+			}
+
+			rst.holder.sessionsMutex.Lock()
+			rst.session.BannedPIDs = append(rst.session.BannedPIDs, rst.joinerClient.PID())
+			rst.holder.sessionsMutex.Unlock()
+
+			_, errCode = rst.joiner.JoinRemoteSession(rst.joinerClient, gatheringID, "", SessionJoinOptions{})
+			if errCode == nil || errCode.ResultCode != nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "").ResultCode {
+				t.Errorf("Joining while banned returned %v, expected PermissionDenied", errCode)
+			}
+
+			if rst.remoteParticipantCount() != 0 {
+				t.Fatalf("Session has %d remote participants after failed joins", rst.remoteParticipantCount())
+			}
+		})
+	}
+}
+
+func TestSessionDirectoryRemoteSessionDeleted(t *testing.T) {
+	for _, implementation := range sessionDirectoryImplementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			holder := newTestMatchmakingManager()
+			holderDirectory, joinerDirectory := implementation.directories(t, holder.Endpoint.LibraryVersions())
+
+			_, err := holder.SetSessionDirectory(holderDirectory, "holder")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			joiner := &recordingDirectoryInstance{unregistered: make(chan uint32, 1)}
+			err = joinerDirectory.RegisterInstance("joiner", joiner)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			session, errCode := holder.CreateSessionByMatchmakeSession(newTestMatchmakeSession(4), nil, types.NewPID(100))
+			if errCode != nil {
+				t.Fatal(errCode.Error())
+			}
+
+			gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+
+			_, errCode = joinerDirectory.Join("joiner", "holder", gatheringID, types.NewPID(200), "", SessionJoinOptions{})
+			if errCode != nil {
+				t.Fatal(errCode.Error())
+			}
+
+			holder.RemoveSession(nil, gatheringID)
+
+			select {
+			case unregisteredGatheringID := <-joiner.unregistered:
+				if unregisteredGatheringID != gatheringID {
+					t.Errorf("Instance was told about gathering %d, expected %d", unregisteredGatheringID, gatheringID)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for the instance of the remote participant to be told")
+			}
+
+			holder.sessionsMutex.RLock()
+			_, ok := holder.remoteParticipants[gatheringID]
+			holder.sessionsMutex.RUnlock()
+
+			if ok {
+				t.Error("The remote participants of the deleted session were kept")
+			}
+		})
+	}
+}
+
+func TestSessionDirectoryInstanceRemoved(t *testing.T) {
+	for _, implementation := range sessionDirectoryImplementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			rst := newRemoteSessionTest(t, implementation.directories)
+
+			rst.join(t, SessionJoinOptions{})
+
+			rst.stopJoiner()
+
+			waitFor(t, "the participants of the removed instance to be dropped", func() bool {
+				return rst.remoteParticipantCount() == 0
+			})
+
+			if rst.participationCount() != 1 {
+				t.Fatalf("Session has a participation count of %d, expected 1", rst.participationCount())
+			}
+		})
+	}
+}
+
+// blockingSessionDirectory is a SessionDirectory whose Publish waits until it is released
+type blockingSessionDirectory struct {
+	SessionDirectory
+	published chan struct{}
+	release   chan struct{}
+}
+
+func (bsd *blockingSessionDirectory) Publish(session *DirectorySession) error {
+	select {
+	case bsd.published <- struct{}{}:
+	default:
+	}
+
+	<-bsd.release
+
+	return bsd.SessionDirectory.Publish(session)
+}
+
+func TestSessionDirectoryPublisherFallsBehind(t *testing.T) {
+	directory := &blockingSessionDirectory{
+		SessionDirectory: NewMemorySessionDirectory(),
+		published:        make(chan struct{}, 1),
+		release:          make(chan struct{}),
+	}
+
+	mm := newTestMatchmakingManager()
+	mm.SessionEventQueueSize = 2
+
+	stop, err := mm.SetSessionDirectory(directory, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createSession := func() uint32 {
+		session, errCode := mm.CreateSessionByMatchmakeSession(newTestMatchmakeSession(4), nil, types.NewPID(1))
+		if errCode != nil {
+			t.Fatal(errCode)
+		}
+
+		return session.GameMatchmakeSession.Gathering.ID.Value
+	}
+
+	// * The publisher is stuck on the first session while the others overflow its queue
+	first := createSession()
+	<-directory.published
+
+	created := []uint32{first}
+	for i := 0; i < 5; i++ {
+		created = append(created, createSession())
+	}
+
+	mm.RemoveSession(nil, created[1])
+	remaining := append([]uint32{created[0]}, created[2:]...)
+
+	close(directory.release)
+
+	waitFor(t, "the sessions to be published again", func() bool {
+		gatheringIDs := listedGatheringIDs(t, directory)["first"]
+		if len(gatheringIDs) != len(remaining) {
+			return false
+		}
+
+		for _, gatheringID := range remaining {
+			if !slices.Contains(gatheringIDs, gatheringID) {
+				return false
+			}
+		}
+
+		return true
+	})
+
+	// * The publisher keeps following the events after catching up
+	latest := createSession()
+	waitFor(t, "a new session to be published", func() bool {
+		return slices.Contains(listedGatheringIDs(t, directory)["first"], latest)
+	})
+
+	stop()
+
+	waitFor(t, "the sessions to be withdrawn", func() bool {
+		return len(listedGatheringIDs(t, directory)["first"]) == 0
+	})
+
+	err = directory.RegisterInstance("first", mm)
+	if err != nil {
+		t.Errorf("The instance should be unregistered once stopped. %s", err.Error())
+	}
+}
@@ -0,0 +1,201 @@
+package common_globals
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// SessionEventType is the type of a SessionEvent
+type SessionEventType uint8
+
+type sessionEventTypes struct {
+	Created         SessionEventType
+	Deleted         SessionEventType
+	PlayerJoined    SessionEventType
+	PlayerLeft      SessionEventType
+	OwnerChanged    SessionEventType
+	HostChanged     SessionEventType // * Only sent when the server migrates the host, not when a participant updates it
+	SpectatorJoined SessionEventType
+	SpectatorLeft   SessionEventType
+}
+
+// SessionEventTypes is an enum of the possible values of SessionEvent.Type
+var SessionEventTypes = sessionEventTypes{
+	Created:         0,
+	Deleted:         1,
+	PlayerJoined:    2,
+	PlayerLeft:      3,
+	OwnerChanged:    4,
+	HostChanged:     5,
+	SpectatorJoined: 6,
+	SpectatorLeft:   7,
+}
+
+// SessionEventReason is the reason of a SessionEvent
+type SessionEventReason uint8
+
+type sessionEventReasons struct {
+	None       SessionEventReason
+	Graceful   SessionEventReason // * The player left or unregistered the session by themselves
+	Disconnect SessionEventReason // * The player disconnected from the server
+	Kick       SessionEventReason // * The player was kicked by the owner or an admin
+	Migration  SessionEventReason // * The server picked a new owner or host
+	Expired    SessionEventReason // * The session was reaped, or its restored participants never came back
+}
+
+// SessionEventReasons is an enum of the possible values of SessionEvent.Reason
+var SessionEventReasons = sessionEventReasons{
+	None:       0,
+	Graceful:   1,
+	Disconnect: 2,
+	Kick:       3,
+	Migration:  4,
+	Expired:    5,
+}
+
+// SessionEvent is a change in the lifecycle of a session
+type SessionEvent struct {
+	Type         SessionEventType
+	GatheringID  uint32
+	PID          *types.PID                           // * Player the event is about. The new owner or host for OwnerChanged and HostChanged. May be nil for Deleted
+	ConnectionID uint32                               // * Connection of PID, if it is known
+	Session      *match_making_types.MatchmakeSession // * Copy of the session at the time of the event
+	Reason       SessionEventReason
+	Time         time.Time
+}
+
+// sessionEventSubscriber queues the events of a subscriber until it receives them,
+// so that a slow subscriber never blocks the manager
+type sessionEventSubscriber struct {
+	mutex        sync.Mutex
+	queue        []*SessionEvent
+	maxQueueSize int
+	signal       chan struct{}
+	done         chan struct{}
+	events       chan *SessionEvent
+	unsubscribe  func()
+}
+
+// push queues an event for the subscriber. Returns false if the queue is full
+func (ses *sessionEventSubscriber) push(event *SessionEvent) bool {
+	ses.mutex.Lock()
+	if len(ses.queue) >= ses.maxQueueSize {
+		ses.mutex.Unlock()
+		return false
+	}
+
+	ses.queue = append(ses.queue, event)
+	ses.mutex.Unlock()
+
+	select {
+	case ses.signal <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+func (ses *sessionEventSubscriber) run() {
+	defer close(ses.events)
+
+	for {
+		ses.mutex.Lock()
+		queue := ses.queue
+		ses.queue = nil
+		ses.mutex.Unlock()
+
+		for _, event := range queue {
+			select {
+			case ses.events <- event:
+			case <-ses.done:
+				return
+			}
+		}
+
+		select {
+		case <-ses.signal:
+		case <-ses.done:
+			return
+		}
+	}
+}
+
+// SubscribeSessionEvents returns a channel which receives every SessionEvent of the manager, in order.
+// Events are delivered asynchronously, so the subscriber may safely call back into the manager.
+//
+// Up to SessionEventQueueSize events are queued while the subscriber is busy. Events are never dropped
+// from a subscription: once the queue is full, the subscriber is unsubscribed and its channel is closed
+// without receiving the queued events, so it can tell it missed some and subscribe again.
+// Returns a function which ends the subscription and closes the channel
+func (mm *MatchmakingManager) SubscribeSessionEvents() (<-chan *SessionEvent, func()) {
+	subscriber := &sessionEventSubscriber{
+		maxQueueSize: mm.SessionEventQueueSize,
+		signal:       make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		events:       make(chan *SessionEvent),
+	}
+
+	var once sync.Once
+	subscriber.unsubscribe = func() {
+		once.Do(func() {
+			mm.sessionEventSubscribers.DeleteAll(subscriber)
+			close(subscriber.done)
+		})
+	}
+
+	mm.sessionEventSubscribers.Add(subscriber)
+
+	go subscriber.run()
+
+	return subscriber.events, subscriber.unsubscribe
+}
+
+// emitSessionEventImpl sends an event about the session and the connection to every subscriber
+func (mm *MatchmakingManager) emitSessionEventImpl(eventType SessionEventType, session *CommonMatchmakeSession, connection *nex.PRUDPConnection, reason SessionEventReason) {
+	if connection == nil {
+		mm.emitPIDSessionEventImpl(eventType, session, nil, 0, reason)
+		return
+	}
+
+	mm.emitPIDSessionEventImpl(eventType, session, connection.PID(), connection.ID, reason)
+}
+
+// emitPIDSessionEventImpl sends an event about the session and the PID to every subscriber.
+// Used directly for players which aren't connected to this endpoint
+func (mm *MatchmakingManager) emitPIDSessionEventImpl(eventType SessionEventType, session *CommonMatchmakeSession, pid *types.PID, connectionID uint32, reason SessionEventReason) {
+	if mm.sessionEventSubscribers.Size() == 0 {
+		return
+	}
+
+	event := &SessionEvent{
+		Type:         eventType,
+		GatheringID:  session.GameMatchmakeSession.Gathering.ID.Value,
+		ConnectionID: connectionID,
+		Session:      session.GameMatchmakeSession.Copy().(*match_making_types.MatchmakeSession),
+		Reason:       reason,
+		Time:         time.Now(),
+	}
+
+	if pid != nil {
+		event.PID = pid.Copy().(*types.PID)
+	}
+
+	overflowedSubscribers := make([]*sessionEventSubscriber, 0)
+	mm.sessionEventSubscribers.Each(func(_ int, subscriber *sessionEventSubscriber) bool {
+		if !subscriber.push(event) {
+			overflowedSubscribers = append(overflowedSubscribers, subscriber)
+		}
+
+		return false
+	})
+
+	// * Unsubscribing modifies the subscribers, so it can't be done while iterating over them
+	for _, subscriber := range overflowedSubscribers {
+		Logger.Warningf("Session event subscriber fell %d events behind, unsubscribing it", subscriber.maxQueueSize)
+		subscriber.unsubscribe()
+	}
+}
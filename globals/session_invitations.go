@@ -0,0 +1,268 @@
+package common_globals
+
+import (
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
+)
+
+// InvitationNotificationConfig holds the notification types used to tell players about invitations.
+// No game is known to receive notifications about invitations, so the server must pick types its game understands
+type InvitationNotificationConfig struct {
+	Category  uint32
+	Received  uint32 // * Sent to the guest
+	Cancelled uint32 // * Sent to the guest
+	Accepted  uint32 // * Sent to the session owner
+	Declined  uint32 // * Sent to the session owner
+}
+
+// sessionInvitation is a pending invitation of a PID into a gathering
+type sessionInvitation struct {
+	guestPID  *types.PID
+	message   string
+	expiresAt time.Time
+}
+
+// pendingInvitationsImpl returns the invitations of a gathering which haven't expired yet, and forgets the expired ones.
+// Requires invitationsMutex to be locked
+func (mm *MatchmakingManager) pendingInvitationsImpl(gatheringID uint32) []*sessionInvitation {
+	now := time.Now()
+	invitations := mm.invitations[gatheringID]
+
+	pending := invitations[:0]
+	for _, invitation := range invitations {
+		if now.Before(invitation.expiresAt) {
+			pending = append(pending, invitation)
+		}
+	}
+
+	if len(pending) == 0 {
+		delete(mm.invitations, gatheringID)
+		return nil
+	}
+
+	mm.invitations[gatheringID] = pending
+
+	return pending
+}
+
+// removeInvitationImpl removes the invitation of a PID into a gathering. Requires invitationsMutex to be locked.
+// Returns false if the PID wasn't invited
+func (mm *MatchmakingManager) removeInvitationImpl(gatheringID uint32, guestPID *types.PID) bool {
+	invitations := mm.pendingInvitationsImpl(gatheringID)
+
+	for index, invitation := range invitations {
+		if invitation.guestPID.Equals(guestPID) {
+			invitations = append(invitations[:index], invitations[index+1:]...)
+			if len(invitations) == 0 {
+				delete(mm.invitations, gatheringID)
+			} else {
+				mm.invitations[gatheringID] = invitations
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// clearInvitations forgets every invitation into a gathering
+func (mm *MatchmakingManager) clearInvitations(gatheringID uint32) {
+	mm.invitationsMutex.Lock()
+	defer mm.invitationsMutex.Unlock()
+
+	delete(mm.invitations, gatheringID)
+}
+
+// sendInvitationNotification sends an invitation notification with the given subtype of InvitationNotifications to the target
+func (mm *MatchmakingManager) sendInvitationNotification(target *types.PID, source *types.PID, subtype uint32, gatheringID uint32, guestPID *types.PID, message string) {
+	oEvent := NewNotificationEvent()
+	oEvent.PIDSource = source
+	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(mm.InvitationNotifications.Category, subtype))
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gatheringID))
+	oEvent.Param2 = types.NewPrimitiveU64(guestPID.Value())
+	oEvent.StrParam = types.NewString(message)
+
+	err := mm.SendNotificationEventToPID(target, oEvent)
+	if err != nil {
+		Logger.Warning(err.Error())
+	}
+}
+
+// InviteToSession invites the given PIDs into the session on behalf of the inviting connection,
+// and notifies them of it if InvitationNotifications is set. Inviting a PID again renews its invitation.
+//
+// The invitations expire after InvitationLifetime, or once the invited PID joins the session
+func (mm *MatchmakingManager) InviteToSession(session *CommonMatchmakeSession, inviter *nex.PRUDPConnection, guestPIDs []*types.PID, message string) {
+	gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+	expiresAt := time.Now().Add(mm.InvitationLifetime)
+
+	mm.invitationsMutex.Lock()
+
+	invitations := mm.pendingInvitationsImpl(gatheringID)
+	for _, guestPID := range guestPIDs {
+		if guestPID.Equals(inviter.PID()) {
+			continue
+		}
+
+		renewed := false
+		for _, invitation := range invitations {
+			if invitation.guestPID.Equals(guestPID) {
+				invitation.message = message
+				invitation.expiresAt = expiresAt
+				renewed = true
+				break
+			}
+		}
+
+		if !renewed {
+			invitations = append(invitations, &sessionInvitation{
+				guestPID:  guestPID,
+				message:   message,
+				expiresAt: expiresAt,
+			})
+		}
+	}
+
+	if len(invitations) != 0 {
+		mm.invitations[gatheringID] = invitations
+	}
+
+	mm.invitationsMutex.Unlock()
+
+	if mm.InvitationNotifications == nil {
+		return
+	}
+
+	for _, guestPID := range guestPIDs {
+		if guestPID.Equals(inviter.PID()) {
+			continue
+		}
+
+		// * Offline guests can still see the invitation later with GetInvitationsReceived
+		mm.sendInvitationNotification(guestPID, inviter.PID(), mm.InvitationNotifications.Received, gatheringID, guestPID, message)
+	}
+}
+
+// CancelSessionInvitations cancels the invitations of the given PIDs into the session, and notifies them of it
+// if InvitationNotifications is set
+func (mm *MatchmakingManager) CancelSessionInvitations(session *CommonMatchmakeSession, canceller *nex.PRUDPConnection, guestPIDs []*types.PID, message string) {
+	gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+
+	cancelledPIDs := make([]*types.PID, 0, len(guestPIDs))
+
+	mm.invitationsMutex.Lock()
+
+	for _, guestPID := range guestPIDs {
+		if mm.removeInvitationImpl(gatheringID, guestPID) {
+			cancelledPIDs = append(cancelledPIDs, guestPID)
+		}
+	}
+
+	mm.invitationsMutex.Unlock()
+
+	if mm.InvitationNotifications == nil {
+		return
+	}
+
+	for _, guestPID := range cancelledPIDs {
+		mm.sendInvitationNotification(guestPID, canceller.PID(), mm.InvitationNotifications.Cancelled, gatheringID, guestPID, message)
+	}
+}
+
+// AcceptSessionInvitation notifies the session owner that the guest accepted their invitation, if InvitationNotifications is set.
+// The invitation is kept until the guest joins the session, so that the join isn't refused.
+// Returns a NEX error code if the guest isn't invited into the session
+func (mm *MatchmakingManager) AcceptSessionInvitation(session *CommonMatchmakeSession, guest *nex.PRUDPConnection, message string) *nex.Error {
+	gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+
+	if !mm.IsInvitedToSession(gatheringID, guest.PID()) {
+		return nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	if mm.InvitationNotifications != nil {
+		mm.sendInvitationNotification(session.GameMatchmakeSession.Gathering.OwnerPID, guest.PID(), mm.InvitationNotifications.Accepted, gatheringID, guest.PID(), message)
+	}
+
+	return nil
+}
+
+// DeclineSessionInvitation removes the invitation of the guest into the session, and notifies the session owner of it
+// if InvitationNotifications is set.
+// Returns a NEX error code if the guest isn't invited into the session
+func (mm *MatchmakingManager) DeclineSessionInvitation(session *CommonMatchmakeSession, guest *nex.PRUDPConnection, message string) *nex.Error {
+	gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+
+	mm.invitationsMutex.Lock()
+	removed := mm.removeInvitationImpl(gatheringID, guest.PID())
+	mm.invitationsMutex.Unlock()
+
+	if !removed {
+		return nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	if mm.InvitationNotifications != nil {
+		mm.sendInvitationNotification(session.GameMatchmakeSession.Gathering.OwnerPID, guest.PID(), mm.InvitationNotifications.Declined, gatheringID, guest.PID(), message)
+	}
+
+	return nil
+}
+
+// IsInvitedToSession checks if the PID has a pending invitation into the gathering
+func (mm *MatchmakingManager) IsInvitedToSession(gatheringID uint32, pid *types.PID) bool {
+	mm.invitationsMutex.Lock()
+	defer mm.invitationsMutex.Unlock()
+
+	for _, invitation := range mm.pendingInvitationsImpl(gatheringID) {
+		if invitation.guestPID.Equals(pid) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetInvitationsSent returns the pending invitations into the gathering
+func (mm *MatchmakingManager) GetInvitationsSent(gatheringID uint32) []*match_making_types.Invitation {
+	mm.invitationsMutex.Lock()
+	defer mm.invitationsMutex.Unlock()
+
+	invitations := mm.pendingInvitationsImpl(gatheringID)
+
+	result := make([]*match_making_types.Invitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		result = append(result, newInvitation(gatheringID, invitation))
+	}
+
+	return result
+}
+
+// GetInvitationsReceived returns the pending invitations of the PID into any gathering
+func (mm *MatchmakingManager) GetInvitationsReceived(pid *types.PID) []*match_making_types.Invitation {
+	mm.invitationsMutex.Lock()
+	defer mm.invitationsMutex.Unlock()
+
+	result := make([]*match_making_types.Invitation, 0)
+	for gatheringID := range mm.invitations {
+		for _, invitation := range mm.pendingInvitationsImpl(gatheringID) {
+			if invitation.guestPID.Equals(pid) {
+				result = append(result, newInvitation(gatheringID, invitation))
+			}
+		}
+	}
+
+	return result
+}
+
+func newInvitation(gatheringID uint32, invitation *sessionInvitation) *match_making_types.Invitation {
+	result := match_making_types.NewInvitation()
+	result.IDGathering = types.NewPrimitiveU32(gatheringID)
+	result.IDGuest = types.NewPrimitiveU32(uint32(invitation.guestPID.Value())) // * Invitations still use the legacy 32 bit PIDs
+	result.StrMessage = types.NewString(invitation.message)
+
+	return result
+}
@@ -0,0 +1,94 @@
+package common_globals
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// SessionJoinOptions holds the options given by a client when explicitly joining a session
+type SessionJoinOptions struct {
+	DontCareMyBlockList bool
+	UserPassword        string
+	SystemPassword      string
+	ParticipationCount  uint16 // * Players joining along with the client, including itself. 0 means a single player
+}
+
+// partySize returns the number of slots the join needs
+func (sjo SessionJoinOptions) partySize() int {
+	if sjo.ParticipationCount == 0 {
+		return 1
+	}
+
+	return int(sjo.ParticipationCount)
+}
+
+// VerifySessionJoin checks if the connection is allowed to join the session.
+// Returns a NEX error code with the reason if it isn't
+func (mm *MatchmakingManager) VerifySessionJoin(connection *nex.PRUDPConnection, session *CommonMatchmakeSession, options SessionJoinOptions) *nex.Error {
+	errCode := mm.runSessionJoinChecks(connection.PID(), session)
+	if errCode != nil {
+		return errCode
+	}
+
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	return mm.verifySessionJoinImpl(connection.PID(), connection, session, options)
+}
+
+// runSessionJoinChecks runs the checks added with AddSessionJoinCheck on a copy of the session.
+// Must be called without sessionsMutex locked
+func (mm *MatchmakingManager) runSessionJoinChecks(pid *types.PID, session *CommonMatchmakeSession) *nex.Error {
+	if len(mm.sessionJoinChecks) == 0 {
+		return nil
+	}
+
+	mm.sessionsMutex.RLock()
+	matchmakeSession := session.GameMatchmakeSession.Copy().(*match_making_types.MatchmakeSession)
+	mm.sessionsMutex.RUnlock()
+
+	for _, check := range mm.sessionJoinChecks {
+		errCode := check(pid, matchmakeSession)
+		if errCode != nil {
+			return errCode
+		}
+	}
+
+	return nil
+}
+
+// verifySessionJoinImpl checks if the player is allowed to join the session, besides the checks added with AddSessionJoinCheck.
+// The connection is nil if the player is connected to another instance.
+// Requires sessionsMutex to be locked
+func (mm *MatchmakingManager) verifySessionJoinImpl(pid *types.PID, connection *nex.PRUDPConnection, session *CommonMatchmakeSession, options SessionJoinOptions) *nex.Error {
+	if session.UserPassword != "" && session.UserPassword != options.UserPassword {
+		return nex.NewError(nex.ResultCodes.RendezVous.MatchmakeSessionUserPasswordUnmatch, "change_error")
+	}
+
+	if session.SystemPassword != "" && session.SystemPassword != options.SystemPassword {
+		return nex.NewError(nex.ResultCodes.RendezVous.MatchmakeSessionSystemPasswordUnmatch, "change_error")
+	}
+
+	if ContainsPID(session.BannedPIDs, pid) {
+		return nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	// * Invited players can join even if the session is closed or doesn't let them participate
+	invited := mm.IsInvitedToSession(session.GameMatchmakeSession.Gathering.ID.Value, pid)
+
+	if !invited && !session.GameMatchmakeSession.OpenParticipation.Value {
+		return nex.NewError(nex.ResultCodes.RendezVous.SessionClosed, "change_error")
+	}
+
+	var friendPIDs []*types.PID
+	if !invited && !mm.canPIDParticipateImpl(pid, connection, session, &friendPIDs) {
+		return nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	if !options.DontCareMyBlockList && mm.isPIDBlockedFromSessionImpl(pid, session, mm.newBlockListCache()) {
+		return nex.NewError(nex.ResultCodes.RendezVous.ParticipantInBlackList, "change_error")
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package common_globals
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
+	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
+)
+
+// isBannedFromSessionImpl checks if the connection was banned from the session
+func isBannedFromSessionImpl(connection *nex.PRUDPConnection, session *CommonMatchmakeSession) bool {
+	return ContainsPID(session.BannedPIDs, connection.PID())
+}
+
+// findParticipantImpl returns the connection of the participant of the session with the given PID, or nil if it isn't there
+func (mm *MatchmakingManager) findParticipantImpl(session *CommonMatchmakeSession, pid *types.PID) *nex.PRUDPConnection {
+	var participant *nex.PRUDPConnection
+	session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		connection := mm.Endpoint.FindConnectionByID(connectionID)
+		if connection != nil && connection.PID().Equals(pid) {
+			participant = connection
+			return true
+		}
+
+		return false
+	})
+
+	return participant
+}
+
+func (mm *MatchmakingManager) kickFromSessionImpl(session *CommonMatchmakeSession, pid *types.PID, ban bool) *nex.Error {
+	gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+
+	if ban && !ContainsPID(session.BannedPIDs, pid) {
+		session.BannedPIDs = append(session.BannedPIDs, pid)
+	}
+
+	// * A banned player can't use an invitation either
+	if ban {
+		mm.invitationsMutex.Lock()
+		mm.removeInvitationImpl(gatheringID, pid)
+		mm.invitationsMutex.Unlock()
+	}
+
+	category := notifications.NotificationCategories.Participation
+	subtype := notifications.NotificationSubTypes.Participation.Ended
+
+	oEvent := NewNotificationEvent()
+	oEvent.PIDSource = pid
+	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gatheringID))
+	oEvent.Param2 = types.NewPrimitiveU64(pid.Value())
+
+	participant := mm.findParticipantImpl(session, pid)
+	if participant == nil {
+		// * The participants don't know about spectators, so only the spectator itself is notified
+		if spectator := mm.findSpectatorImpl(session, pid); spectator != nil {
+			if mm.SessionManagementDebugLog {
+				globals.Logger.Infof("GID %d: Kicked spectator PID %d", gatheringID, pid.Value())
+			}
+
+			err := mm.SendNotificationEvent(spectator, oEvent)
+			if err != nil {
+				Logger.Warning(err.Error())
+			}
+
+			mm.removeSpectatorFromSessionImpl(spectator, session, SessionEventReasons.Kick)
+
+			return nil
+		}
+
+		if ban {
+			return nil
+		}
+
+		return nex.NewError(nex.ResultCodes.RendezVous.NotParticipatedGathering, "change_error")
+	}
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Kicked PID %d", gatheringID, pid.Value())
+	}
+
+	// * The owner is notified when the participant is removed, so leave them out here
+	ownerPID := session.GameMatchmakeSession.Gathering.OwnerPID
+	targets := make([]uint32, 0, session.ConnectionIDs.Size())
+	session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		connection := mm.Endpoint.FindConnectionByID(connectionID)
+		if connection == nil || !connection.PID().Equals(ownerPID) {
+			targets = append(targets, connectionID)
+		}
+
+		return false
+	})
+
+	logNotificationDeliveryFailures(mm.SendNotificationEventToConnectionIDs(targets, oEvent))
+
+	mm.removeConnectionIDFromSessionImpl(participant, gatheringID, SessionEventReasons.Kick)
+
+	return nil
+}
+
+// KickFromSession removes a participant from the session on behalf of its owner, and notifies every participant of it.
+// If ban is set, the player is also prevented from finding and joining the session again.
+// Spectators can be kicked too, in which case only they are notified.
+// Returns a NEX error code if the kicking connection isn't the session owner, or if the player isn't a member
+func (mm *MatchmakingManager) KickFromSession(owner *nex.PRUDPConnection, gatheringID uint32, pid *types.PID, ban bool) *nex.Error {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	session, ok := mm.sessions.Get(gatheringID)
+	if !ok {
+		return nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	if !session.GameMatchmakeSession.Gathering.OwnerPID.Equals(owner.PID()) {
+		return nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	// * Owners leave their sessions instead
+	if pid.Equals(owner.PID()) {
+		return nex.NewError(nex.ResultCodes.RendezVous.InvalidOperation, "change_error")
+	}
+
+	return mm.kickFromSessionImpl(session, pid, ban)
+}
+
+// AdminKickFromSession removes a participant from the session without any permission check, such as the session owner.
+// The behavior is otherwise the same as KickFromSession. Banning a player who isn't a participant is allowed
+func (mm *MatchmakingManager) AdminKickFromSession(gatheringID uint32, pid *types.PID, ban bool) *nex.Error {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	session, ok := mm.sessions.Get(gatheringID)
+	if !ok {
+		return nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	return mm.kickFromSessionImpl(session, pid, ban)
+}
+
+// UnbanFromSession lets a banned player find and join the session again
+func (mm *MatchmakingManager) UnbanFromSession(gatheringID uint32, pid *types.PID) *nex.Error {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	session, ok := mm.sessions.Get(gatheringID)
+	if !ok {
+		return nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	bannedPIDs := make([]*types.PID, 0, len(session.BannedPIDs))
+	for _, bannedPID := range session.BannedPIDs {
+		if !bannedPID.Equals(pid) {
+			bannedPIDs = append(bannedPIDs, bannedPID)
+		}
+	}
+
+	session.BannedPIDs = bannedPIDs
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package common_globals
+
+import "sort"
+
+// SessionComparator reports whether a session must be placed before another one
+type SessionComparator func(a, b *CommonMatchmakeSession) bool
+
+// SessionsByGatheringID places the sessions in ascending gathering ID order, which is also their creation order
+func SessionsByGatheringID(a, b *CommonMatchmakeSession) bool {
+	return a.GameMatchmakeSession.Gathering.ID.Value < b.GameMatchmakeSession.Gathering.ID.Value
+}
+
+// SessionsByCreationTime places the oldest sessions first
+func SessionsByCreationTime(a, b *CommonMatchmakeSession) bool {
+	aStartedTime := a.GameMatchmakeSession.StartedTime.Value()
+	bStartedTime := b.GameMatchmakeSession.StartedTime.Value()
+
+	if aStartedTime != bStartedTime {
+		return aStartedTime < bStartedTime
+	}
+
+	return SessionsByGatheringID(a, b)
+}
+
+// SessionsByFillLevel places the sessions with the fewest free slots first
+func SessionsByFillLevel(a, b *CommonMatchmakeSession) bool {
+	aFreeSlots := int(a.GameMatchmakeSession.MaximumParticipants.Value) - a.OccupiedSlots()
+	bFreeSlots := int(b.GameMatchmakeSession.MaximumParticipants.Value) - b.OccupiedSlots()
+
+	if aFreeSlots != bFreeSlots {
+		return aFreeSlots < bFreeSlots
+	}
+
+	return SessionsByGatheringID(a, b)
+}
+
+// sortSessionsImpl sorts the sessions using SessionOrder, or by gathering ID if it isn't set
+func (mm *MatchmakingManager) sortSessionsImpl(sessions []*CommonMatchmakeSession) {
+	less := mm.SessionOrder
+	if less == nil {
+		less = SessionsByGatheringID
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return less(sessions[i], sessions[j])
+	})
+}
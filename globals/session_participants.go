@@ -0,0 +1,81 @@
+package common_globals
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+)
+
+// canViewSessionImpl checks if the connection is allowed to see the participants of the session.
+//
+// The participants, the spectators and the invited players can always see them. Anyone else must be able to take part
+// in the session, and the session must not be password protected
+func (mm *MatchmakingManager) canViewSessionImpl(connection *nex.PRUDPConnection, session *CommonMatchmakeSession) bool {
+	if session.MemberRole(connection.ID) != SessionMemberRoles.None {
+		return true
+	}
+
+	if isBannedFromSessionImpl(connection, session) {
+		return false
+	}
+
+	if mm.IsInvitedToSession(session.GameMatchmakeSession.Gathering.ID.Value, connection.PID()) {
+		return true
+	}
+
+	if session.UserPassword != "" || session.SystemPassword != "" {
+		return false
+	}
+
+	var friendPIDs []*types.PID
+	if !mm.canParticipateImpl(connection, session, &friendPIDs) {
+		return false
+	}
+
+	return !mm.isBlockedFromSessionImpl(connection, session, mm.newBlockListCache())
+}
+
+// GetSessionParticipants returns the connections of the participants of the session. Spectators aren't included.
+// Participants which can't be found on the endpoint are left out.
+// Returns a NEX error code if the connection isn't allowed to see them
+func (mm *MatchmakingManager) GetSessionParticipants(connection *nex.PRUDPConnection, session *CommonMatchmakeSession) ([]*nex.PRUDPConnection, *nex.Error) {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	if !mm.canViewSessionImpl(connection, session) {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	participants := make([]*nex.PRUDPConnection, 0, session.ConnectionIDs.Size())
+	session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		participant := mm.Endpoint.FindConnectionByID(connectionID)
+		if participant == nil {
+			Logger.Warning("Player not found")
+			return false
+		}
+
+		participants = append(participants, participant)
+
+		return false
+	})
+
+	return participants, nil
+}
+
+// FindSessionsByOwner returns the sessions owned by the given PID which the connection is allowed to see, in SessionOrder
+func (mm *MatchmakingManager) FindSessionsByOwner(connection *nex.PRUDPConnection, ownerPID *types.PID) []*CommonMatchmakeSession {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	sessions := make([]*CommonMatchmakeSession, 0)
+	mm.sessions.Each(func(_ uint32, session *CommonMatchmakeSession) bool {
+		if session.GameMatchmakeSession.Gathering.OwnerPID.Equals(ownerPID) && mm.canViewSessionImpl(connection, session) {
+			sessions = append(sessions, session)
+		}
+
+		return false
+	})
+
+	mm.sortSessionsImpl(sessions)
+
+	return sessions
+}
@@ -0,0 +1,142 @@
+package common_globals
+
+import (
+	"sort"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+)
+
+// SessionRankingConfig configures how the candidate sessions of an automatic matchmake are ranked.
+//
+// Every candidate gets a score between 0 and 1 for each criteria, which are then weighted and added together.
+// The candidate with the highest score is picked. A criteria which can't be scored, such as the skill of
+// a player without a rating, gets a neutral score of 0.5
+type SessionRankingConfig struct {
+	SkillWeight       float64
+	LatencyWeight     float64
+	FillWeight        float64
+	RatingSpread      float64       // * Rating difference at which the skill score is halved. The default is used if not positive
+	LatencySpread     time.Duration // * Latency at which the latency score is halved. The default is used if not positive
+	GetPlayerRating   func(pid *types.PID) (float64, bool)
+	GetSessionLatency func(connection *nex.PRUDPConnection, session *CommonMatchmakeSession) (time.Duration, bool)
+}
+
+// NewSessionRankingConfig returns a new SessionRankingConfig with equal weights.
+// The spreads are suited for Elo-like ratings
+func NewSessionRankingConfig() *SessionRankingConfig {
+	return &SessionRankingConfig{
+		SkillWeight:   1,
+		LatencyWeight: 1,
+		FillWeight:    1,
+		RatingSpread:  defaultRatingSpread,
+		LatencySpread: defaultLatencySpread,
+	}
+}
+
+const (
+	defaultRatingSpread  float64       = 200
+	defaultLatencySpread time.Duration = 100 * time.Millisecond
+)
+
+// sessionRatingImpl returns the average rating of the connected participants of a session
+func (mm *MatchmakingManager) sessionRatingImpl(session *CommonMatchmakeSession) (float64, bool) {
+	var total float64
+	var rated int
+
+	session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		target := mm.Endpoint.FindConnectionByID(connectionID)
+		if target == nil {
+			return false
+		}
+
+		if rating, ok := mm.SessionRanking.GetPlayerRating(target.PID()); ok {
+			total += rating
+			rated++
+		}
+
+		return false
+	})
+
+	if rated == 0 {
+		return 0, false
+	}
+
+	return total / float64(rated), true
+}
+
+func (mm *MatchmakingManager) scoreSessionImpl(connection *nex.PRUDPConnection, session *CommonMatchmakeSession, playerRating float64, playerRated bool) float64 {
+	config := mm.SessionRanking
+
+	// * A spread of zero would divide zero by zero when the difference is zero too
+	ratingSpread := config.RatingSpread
+	if ratingSpread <= 0 {
+		ratingSpread = defaultRatingSpread
+	}
+
+	latencySpread := config.LatencySpread
+	if latencySpread <= 0 {
+		latencySpread = defaultLatencySpread
+	}
+
+	skillScore := 0.5
+	if playerRated {
+		if sessionRating, ok := mm.sessionRatingImpl(session); ok {
+			difference := playerRating - sessionRating
+			if difference < 0 {
+				difference = -difference
+			}
+
+			skillScore = 1 / (1 + difference/ratingSpread)
+		}
+	}
+
+	latencyScore := 0.5
+	if config.GetSessionLatency != nil {
+		if latency, ok := config.GetSessionLatency(connection, session); ok {
+			latencyScore = 1 / (1 + float64(latency)/float64(latencySpread))
+		}
+	}
+
+	fillScore := 0.5
+	if maximumParticipants := session.GameMatchmakeSession.MaximumParticipants.Value; maximumParticipants != 0 {
+		fillScore = float64(mm.sessionParticipantCountImpl(session)) / float64(maximumParticipants)
+	}
+
+	return config.SkillWeight*skillScore + config.LatencyWeight*latencyScore + config.FillWeight*fillScore
+}
+
+// rankSessionsImpl sorts the sessions from the best to the worst fit for the connection.
+// Sessions with the same score keep their relative order
+func (mm *MatchmakingManager) rankSessionsImpl(connection *nex.PRUDPConnection, sessions []*CommonMatchmakeSession) []*CommonMatchmakeSession {
+	if mm.SessionRanking == nil || len(sessions) < 2 {
+		return sessions
+	}
+
+	var playerRating float64
+	var playerRated bool
+	if mm.SessionRanking.GetPlayerRating != nil {
+		playerRating, playerRated = mm.SessionRanking.GetPlayerRating(connection.PID())
+	}
+
+	scores := make(map[*CommonMatchmakeSession]float64, len(sessions))
+	for _, session := range sessions {
+		scores[session] = mm.scoreSessionImpl(connection, session, playerRating, playerRated)
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return scores[sessions[i]] > scores[sessions[j]]
+	})
+
+	return sessions
+}
+
+// RankSessions sorts the sessions from the best to the worst fit for the connection, using the SessionRanking config.
+// The order is left untouched if SessionRanking is not set
+func (mm *MatchmakingManager) RankSessions(connection *nex.PRUDPConnection, sessions []*CommonMatchmakeSession) []*CommonMatchmakeSession {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	return mm.rankSessionsImpl(connection, sessions)
+}
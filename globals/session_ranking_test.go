@@ -0,0 +1,44 @@
+package common_globals
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+)
+
+func TestSessionRankingNonPositiveSpreads(t *testing.T) {
+	mm := newTestMatchmakingManager()
+	searcher := newTestEndpointConnection(mm, 1, 100)
+	host := newTestEndpointConnection(mm, 2, 200)
+
+	mm.SessionRanking = &SessionRankingConfig{
+		SkillWeight:   1,
+		LatencyWeight: 1,
+		FillWeight:    1,
+		RatingSpread:  0,
+		LatencySpread: -time.Millisecond,
+		GetPlayerRating: func(pid *types.PID) (float64, bool) {
+			return 1000, true
+		},
+		GetSessionLatency: func(connection *nex.PRUDPConnection, session *CommonMatchmakeSession) (time.Duration, bool) {
+			return 0, true
+		},
+	}
+
+	session, errCode := mm.CreateSessionByMatchmakeSession(newTestMatchmakeSession(4), newTestMatchmakeSession(4), host.PID())
+	if errCode != nil {
+		t.Fatal(errCode)
+	}
+
+	session.ConnectionIDs.Add(host.ID)
+
+	// * Same rating and no latency, so the skill and latency scores are at their maximum with any positive spread,
+	// * and the session is a quarter full
+	score := mm.scoreSessionImpl(searcher, session, 1000, true)
+	if math.IsNaN(score) || score != 2.25 {
+		t.Errorf("The session scored %v, expected 2.25", score)
+	}
+}
@@ -0,0 +1,102 @@
+package common_globals
+
+import (
+	"time"
+
+	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
+)
+
+// SessionReaperConfig holds the limits enforced by the session reaper. Limits set to 0 are not enforced
+type SessionReaperConfig struct {
+	MaxAge                  time.Duration // * Time since the session was created
+	IdleTimeout             time.Duration // * Time since the last UpdateProgressScore or ModifyCurrentGameAttribute
+	HostDisconnectedTimeout time.Duration // * Time the session host has been disconnected from the server
+}
+
+// sessionReaper holds the state of a running session reaper
+type sessionReaper struct {
+	config           SessionReaperConfig
+	hostMissingSince map[uint32]time.Time
+}
+
+// reapImpl unregisters the sessions which went past any of the limits, notifying their participants
+func (mm *MatchmakingManager) reapImpl(reaper *sessionReaper, now time.Time) {
+	expired := make(map[uint32]string)
+
+	mm.sessions.Each(func(gatheringID uint32, session *CommonMatchmakeSession) bool {
+		config := reaper.config
+
+		if config.MaxAge != 0 && now.Sub(session.GameMatchmakeSession.StartedTime.Standard()) > config.MaxAge {
+			expired[gatheringID] = "max age reached"
+			return false
+		}
+
+		if config.IdleTimeout != 0 && now.Sub(session.LastActivity()) > config.IdleTimeout {
+			expired[gatheringID] = "idle"
+			return false
+		}
+
+		if config.HostDisconnectedTimeout != 0 {
+			if isSessionHostConnected(session, mm.Endpoint) {
+				delete(reaper.hostMissingSince, gatheringID)
+				return false
+			}
+
+			missingSince, ok := reaper.hostMissingSince[gatheringID]
+			if !ok {
+				reaper.hostMissingSince[gatheringID] = now
+			} else if now.Sub(missingSince) > config.HostDisconnectedTimeout {
+				expired[gatheringID] = "host disconnected"
+			}
+		}
+
+		return false
+	})
+
+	for gatheringID, reason := range expired {
+		if mm.SessionManagementDebugLog {
+			globals.Logger.Infof("GID %d: Reaped, %s", gatheringID, reason)
+		}
+
+		delete(reaper.hostMissingSince, gatheringID)
+		mm.removeSessionImpl(nil, gatheringID, SessionEventReasons.Expired)
+	}
+
+	// * Forget the sessions which were deleted in the meantime
+	for gatheringID := range reaper.hostMissingSince {
+		if _, ok := mm.sessions.Get(gatheringID); !ok {
+			delete(reaper.hostMissingSince, gatheringID)
+		}
+	}
+}
+
+// StartSessionReaper checks every interval for sessions which went past the limits in the config,
+// and unregisters them with a GatheringUnregistered notification.
+// Returns a function which stops the reaper
+func (mm *MatchmakingManager) StartSessionReaper(config SessionReaperConfig, interval time.Duration) func() {
+	reaper := &sessionReaper{
+		config:           config,
+		hostMissingSince: make(map[uint32]time.Time),
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				mm.sessionsMutex.Lock()
+				mm.reapImpl(reaper, now)
+				mm.sessionsMutex.Unlock()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,223 @@
+package common_globals
+
+import (
+	"math/bits"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+type matchmakeSelectionMethods struct {
+	Random                        uint32
+	NearestNeighbor               uint32
+	BroadenRange                  uint32
+	ProgressScore                 uint32
+	BroadenRangeWithProgressScore uint32
+	ScoreBased                    uint32
+}
+
+// MatchmakeSelectionMethods is an enum of the possible values of MatchmakeSessionSearchCriteria.SelectionMethod
+var MatchmakeSelectionMethods = matchmakeSelectionMethods{
+	Random:                        0,
+	NearestNeighbor:               1,
+	BroadenRange:                  2,
+	ProgressScore:                 3,
+	BroadenRangeWithProgressScore: 4,
+	ScoreBased:                    5,
+}
+
+// searchedAttributeValue returns the value searched by an attribute of a search criteria, using the middle of searched ranges.
+// Returns false if the attribute accepts any value
+func searchedAttributeValue(search string) (int64, bool) {
+	if search == "" {
+		return 0, false
+	}
+
+	before, after, found := strings.Cut(search, ",")
+
+	min, err := strconv.ParseUint(before, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	if !found {
+		return int64(min), true
+	}
+
+	max, err := strconv.ParseUint(after, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return (int64(min) + int64(max)) / 2, true
+}
+
+// attributeTargets returns the attribute values which the distance based selection methods compare the sessions against, keyed by attribute index.
+// They are the attributes of the referred gathering if there is one, and the values searched by the criteria otherwise
+func attributeTargets(criteria *match_making_types.MatchmakeSessionSearchCriteria, referSession *CommonMatchmakeSession) map[int]int64 {
+	targets := make(map[int]int64)
+
+	if referSession != nil {
+		for index, attribute := range referSession.GameMatchmakeSession.Attributes.Slice() {
+			targets[index] = int64(attribute.Value)
+		}
+
+		return targets
+	}
+
+	for index, attribute := range criteria.Attribs.Slice() {
+		if value, ok := searchedAttributeValue(attribute.Value); ok {
+			targets[index] = value
+		}
+	}
+
+	return targets
+}
+
+// attributeDistance returns the sum of the distances between the attributes of the session and the targets
+func attributeDistance(session *CommonMatchmakeSession, targets map[int]int64) int64 {
+	var distance int64
+	for index, attribute := range session.GameMatchmakeSession.Attributes.Slice() {
+		if target, ok := targets[index]; ok {
+			distance += absoluteDifference(int64(attribute.Value), target)
+		}
+	}
+
+	return distance
+}
+
+// broadenedRange returns the step at which a range broadening around the target reaches the given distance.
+// The range starts at the target itself and doubles at every step, and the sessions reached at the same step are left in order
+func broadenedRange(distance int64) int {
+	return bits.Len64(uint64(distance))
+}
+
+func absoluteDifference(a int64, b int64) int64 {
+	if a < b {
+		return b - a
+	}
+
+	return a - b
+}
+
+// applySelectionMethodImpl reorders the sessions found with a search criteria following its selection method.
+//
+// The distance based methods compare the attributes of the sessions with the ones of the referred gathering,
+// or with the values searched by the criteria if there is none. NearestNeighbor sorts the sessions by that distance,
+// while BroadenRange only prefers the sessions found with a narrower range, keeping SessionOrder within each range.
+// The progress score methods compare with the progress score of the referred gathering, or 0 if there is none.
+//
+// Random selection leaves the order untouched, and is instead applied when a single session is picked
+// out of the found ones. This keeps browsing results in SessionOrder
+func (mm *MatchmakingManager) applySelectionMethodImpl(sessions []*CommonMatchmakeSession, criteria *match_making_types.MatchmakeSessionSearchCriteria) {
+	var referSession *CommonMatchmakeSession
+	if criteria.ReferGID.Value != 0 {
+		referSession, _ = mm.sessions.Get(criteria.ReferGID.Value)
+	}
+
+	// * Prefer the progress score nearest to the one of the referred gathering,
+	// * or the sessions which have progressed the least if there is none
+	var targetProgressScore int64
+	if referSession != nil {
+		targetProgressScore = int64(referSession.GameMatchmakeSession.ProgressScore.Value)
+	}
+
+	progressScoreDistance := func(session *CommonMatchmakeSession) int64 {
+		return absoluteDifference(int64(session.GameMatchmakeSession.ProgressScore.Value), targetProgressScore)
+	}
+
+	switch criteria.SelectionMethod.Value {
+	case MatchmakeSelectionMethods.NearestNeighbor:
+		targets := attributeTargets(criteria, referSession)
+
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return attributeDistance(sessions[i], targets) < attributeDistance(sessions[j], targets)
+		})
+	case MatchmakeSelectionMethods.BroadenRange:
+		targets := attributeTargets(criteria, referSession)
+
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return broadenedRange(attributeDistance(sessions[i], targets)) < broadenedRange(attributeDistance(sessions[j], targets))
+		})
+	case MatchmakeSelectionMethods.ProgressScore:
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return progressScoreDistance(sessions[i]) < progressScoreDistance(sessions[j])
+		})
+	case MatchmakeSelectionMethods.BroadenRangeWithProgressScore:
+		// * The progress score decides between the sessions found with the same range
+		targets := attributeTargets(criteria, referSession)
+
+		sort.SliceStable(sessions, func(i, j int) bool {
+			rangeI := broadenedRange(attributeDistance(sessions[i], targets))
+			rangeJ := broadenedRange(attributeDistance(sessions[j], targets))
+			if rangeI != rangeJ {
+				return rangeI < rangeJ
+			}
+
+			return progressScoreDistance(sessions[i]) < progressScoreDistance(sessions[j])
+		})
+	case MatchmakeSelectionMethods.ScoreBased:
+		if referSession == nil {
+			return
+		}
+
+		// * Prefer the sessions sharing the most attributes with the referred gathering
+		referAttributes := referSession.GameMatchmakeSession.Attributes.Slice()
+		score := func(session *CommonMatchmakeSession) int {
+			matchingAttributes := 0
+			for index, attribute := range session.GameMatchmakeSession.Attributes.Slice() {
+				if index < len(referAttributes) && attribute.Value == referAttributes[index].Value {
+					matchingAttributes++
+				}
+			}
+
+			return matchingAttributes
+		}
+
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return score(sessions[i]) > score(sessions[j])
+		})
+	}
+}
+
+// FindSessionToJoin finds the session an automatic matchmake using the given search criterias should join.
+//
+// The sessions of the first search criteria with a joinable session are considered. They are ranked if
+// SessionRanking is set. Otherwise, a random one is picked if the search criteria uses random selection,
+// and the first one in order if not. Only sessions with room for the whole party of participationCount players are
+// considered, 0 meaning a single player. Returns nil if no session can be joined
+func (mm *MatchmakingManager) FindSessionToJoin(connection *nex.PRUDPConnection, searchCriterias []*match_making_types.MatchmakeSessionSearchCriteria, gameSpecificChecks func(searchCriteria *match_making_types.MatchmakeSessionSearchCriteria, matchmakeSession *match_making_types.MatchmakeSession) bool, participationCount uint16) *CommonMatchmakeSession {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	sessionGroups := mm.findSessionGroupsBySearchCriteriasImpl(connection, searchCriterias, gameSpecificChecks, nil)
+
+	partySize := int(participationCount)
+	if partySize == 0 {
+		partySize = 1
+	}
+
+	for criteriaIndex, sessionGroup := range sessionGroups {
+		// * The search criteria may allow full or locked sessions, which can't be joined
+		sessions := FilterJoinableSessions(sessionGroup, partySize)
+		if len(sessions) == 0 {
+			continue
+		}
+
+		if mm.SessionRanking != nil {
+			return mm.rankSessionsImpl(connection, sessions)[0]
+		}
+
+		if searchCriterias[criteriaIndex].SelectionMethod.Value == MatchmakeSelectionMethods.Random {
+			return sessions[rand.Intn(len(sessions))]
+		}
+
+		return sessions[0]
+	}
+
+	return nil
+}
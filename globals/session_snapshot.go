@@ -0,0 +1,474 @@
+package common_globals
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	"golang.org/x/exp/slices"
+)
+
+const sessionSnapshotVersion uint32 = 1
+
+// pendingMember is a member of a restored session which hasn't reconnected yet
+type pendingMember struct {
+	pid           *types.PID
+	role          SessionMemberRole
+	reservedSlots int // * Slots held for the guests of a participant
+}
+
+// SnapshotBackend stores the session snapshots of a MatchmakingManager
+type SnapshotBackend interface {
+	// SaveSnapshot stores a snapshot, replacing the previous one
+	SaveSnapshot(data []byte) error
+
+	// LoadSnapshot returns the last stored snapshot. Returns nil data if there is no snapshot
+	LoadSnapshot() ([]byte, error)
+}
+
+// FileSnapshotBackend is a SnapshotBackend which keeps the snapshot in a file on disk
+type FileSnapshotBackend struct {
+	Path string
+}
+
+// SaveSnapshot writes the snapshot to a temporary file and moves it over the previous one,
+// so a crash while saving never leaves a partially written snapshot behind
+func (fsb *FileSnapshotBackend) SaveSnapshot(data []byte) error {
+	temporaryPath := fsb.Path + ".tmp"
+
+	err := os.WriteFile(temporaryPath, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, fsb.Path)
+}
+
+// LoadSnapshot reads the snapshot file. Returns nil data if the file doesn't exist
+func (fsb *FileSnapshotBackend) LoadSnapshot() ([]byte, error) {
+	data, err := os.ReadFile(fsb.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+// NewFileSnapshotBackend returns a new FileSnapshotBackend which uses the given path
+func NewFileSnapshotBackend(path string) *FileSnapshotBackend {
+	return &FileSnapshotBackend{Path: path}
+}
+
+// snapshotStreamSettings returns the stream settings used by the snapshots.
+// PIDs are always stored with their full width, and structure headers are never used
+func snapshotStreamSettings() *nex.ByteStreamSettings {
+	settings := nex.NewByteStreamSettings()
+	settings.StringLengthSize = 4
+	settings.PIDSize = 8
+
+	return settings
+}
+
+// sessionMembersImpl returns the PIDs of the connected participants of a session along with the slots reserved for their guests,
+// and the PIDs of its connected spectators. Both are followed by the members which haven't reconnected since the session was restored
+func (mm *MatchmakingManager) sessionMembersImpl(gatheringID uint32, session *CommonMatchmakeSession) (*types.List[*types.PID], *types.List[*types.PrimitiveU32], *types.List[*types.PID]) {
+	participants := types.NewList[*types.PID]()
+	participants.Type = types.NewPID(0)
+
+	reservedSlots := types.NewList[*types.PrimitiveU32]()
+	reservedSlots.Type = types.NewPrimitiveU32(0)
+
+	spectators := types.NewList[*types.PID]()
+	spectators.Type = types.NewPID(0)
+
+	session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		target := mm.Endpoint.FindConnectionByID(connectionID)
+		if target != nil {
+			participants.Append(target.PID())
+			reservedSlots.Append(types.NewPrimitiveU32(uint32(session.PartySize(connectionID) - 1)))
+		}
+
+		return false
+	})
+
+	session.SpectatorConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		target := mm.Endpoint.FindConnectionByID(connectionID)
+		if target != nil {
+			spectators.Append(target.PID())
+		}
+
+		return false
+	})
+
+	for _, member := range mm.pendingMembers[gatheringID] {
+		if member.role == SessionMemberRoles.Spectator {
+			spectators.Append(member.pid)
+		} else {
+			participants.Append(member.pid)
+			reservedSlots.Append(types.NewPrimitiveU32(uint32(member.reservedSlots)))
+		}
+	}
+
+	return participants, reservedSlots, spectators
+}
+
+// bannedPIDsList returns the banned PIDs of a session as a list
+func bannedPIDsList(session *CommonMatchmakeSession) *types.List[*types.PID] {
+	bannedPIDs := types.NewList[*types.PID]()
+	bannedPIDs.Type = types.NewPID(0)
+	bannedPIDs.SetFromData(session.BannedPIDs)
+
+	return bannedPIDs
+}
+
+// Snapshot serializes every session, along with the PIDs of their participants and spectators,
+// the slots reserved for the guests of the participants and the banned PIDs.
+//
+// The snapshot can only be restored by an endpoint using the same library versions
+func (mm *MatchmakingManager) Snapshot() []byte {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	stream := nex.NewByteStreamOut(mm.Endpoint.LibraryVersions(), snapshotStreamSettings())
+
+	stream.WritePrimitiveUInt32LE(sessionSnapshotVersion)
+	stream.WritePrimitiveUInt32LE(mm.CurrentGatheringID.Value)
+	stream.WritePrimitiveUInt32LE(uint32(mm.sessions.Len()))
+
+	mm.sessions.Each(func(gatheringID uint32, session *CommonMatchmakeSession) bool {
+		stream.WritePrimitiveUInt32LE(gatheringID)
+
+		session.GameMatchmakeSession.WriteTo(stream)
+
+		stream.WritePrimitiveBool(session.SearchMatchmakeSession != nil)
+		if session.SearchMatchmakeSession != nil {
+			session.SearchMatchmakeSession.WriteTo(stream)
+		}
+
+		participants, reservedSlots, spectators := mm.sessionMembersImpl(gatheringID, session)
+
+		participants.WriteTo(stream)
+
+		types.NewString(session.UserPassword).WriteTo(stream)
+		types.NewString(session.SystemPassword).WriteTo(stream)
+
+		bannedPIDsList(session).WriteTo(stream)
+		reservedSlots.WriteTo(stream)
+		spectators.WriteTo(stream)
+
+		return false
+	})
+
+	return stream.Bytes()
+}
+
+// SaveSnapshot serializes every session and stores the snapshot in the given backend
+func (mm *MatchmakingManager) SaveSnapshot(backend SnapshotBackend) error {
+	return backend.SaveSnapshot(mm.Snapshot())
+}
+
+// StartPeriodicSnapshots saves a snapshot to the given backend on every interval.
+// Returns a function which stops the snapshots
+func (mm *MatchmakingManager) StartPeriodicSnapshots(backend SnapshotBackend, interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				err := mm.SaveSnapshot(backend)
+				if err != nil {
+					Logger.Error(err.Error())
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
+// RestoreSnapshot replaces the current sessions with the ones in the snapshot.
+//
+// The restored sessions start without any connection. Their participants and spectators are added back to them
+// as they reconnect, see RestoreConnection. CurrentGatheringID is advanced past every restored
+// gathering ID, so new sessions never reuse them. The OnSessionCreated handlers are not called
+func (mm *MatchmakingManager) RestoreSnapshot(data []byte) error {
+	stream := nex.NewByteStreamIn(data, mm.Endpoint.LibraryVersions(), snapshotStreamSettings())
+
+	version, err := stream.ReadPrimitiveUInt32LE()
+	if err != nil {
+		return fmt.Errorf("Failed to read snapshot version. %s", err.Error())
+	}
+
+	if version != sessionSnapshotVersion {
+		return fmt.Errorf("Unsupported snapshot version %d", version)
+	}
+
+	currentGatheringID, err := stream.ReadPrimitiveUInt32LE()
+	if err != nil {
+		return fmt.Errorf("Failed to read snapshot gathering ID counter. %s", err.Error())
+	}
+
+	sessionCount, err := stream.ReadPrimitiveUInt32LE()
+	if err != nil {
+		return fmt.Errorf("Failed to read snapshot session count. %s", err.Error())
+	}
+
+	sessions := make(map[uint32]*CommonMatchmakeSession, sessionCount)
+	pendingMembers := make(map[uint32][]*pendingMember, sessionCount)
+	var pendingMemberCount int32
+
+	for i := 0; i < int(sessionCount); i++ {
+		gatheringID, err := stream.ReadPrimitiveUInt32LE()
+		if err != nil {
+			return fmt.Errorf("Failed to read snapshot gathering ID. %s", err.Error())
+		}
+
+		gameMatchmakeSession := match_making_types.NewMatchmakeSession()
+		err = gameMatchmakeSession.ExtractFrom(stream)
+		if err != nil {
+			return fmt.Errorf("Failed to read snapshot of GID %d. %s", gatheringID, err.Error())
+		}
+
+		hasSearchMatchmakeSession, err := stream.ReadPrimitiveBool()
+		if err != nil {
+			return fmt.Errorf("Failed to read snapshot of GID %d. %s", gatheringID, err.Error())
+		}
+
+		var searchMatchmakeSession *match_making_types.MatchmakeSession
+		if hasSearchMatchmakeSession {
+			searchMatchmakeSession = match_making_types.NewMatchmakeSession()
+			err = searchMatchmakeSession.ExtractFrom(stream)
+			if err != nil {
+				return fmt.Errorf("Failed to read snapshot of GID %d. %s", gatheringID, err.Error())
+			}
+		}
+
+		participants := types.NewList[*types.PID]()
+		participants.Type = types.NewPID(0)
+		err = participants.ExtractFrom(stream)
+		if err != nil {
+			return fmt.Errorf("Failed to read participants of GID %d. %s", gatheringID, err.Error())
+		}
+
+		session := &CommonMatchmakeSession{
+			GameMatchmakeSession:   gameMatchmakeSession,
+			SearchMatchmakeSession: searchMatchmakeSession,
+			ConnectionIDs:          nex.NewMutexSlice[uint32](),
+			SpectatorConnectionIDs: nex.NewMutexSlice[uint32](),
+		}
+
+		// * Idle time isn't kept in snapshots, so restored sessions start over
+		session.MarkActivity()
+
+		userPassword := types.NewString("")
+		err = userPassword.ExtractFrom(stream)
+		if err != nil {
+			return fmt.Errorf("Failed to read user password of GID %d. %s", gatheringID, err.Error())
+		}
+
+		systemPassword := types.NewString("")
+		err = systemPassword.ExtractFrom(stream)
+		if err != nil {
+			return fmt.Errorf("Failed to read system password of GID %d. %s", gatheringID, err.Error())
+		}
+
+		session.UserPassword = userPassword.Value
+		session.SystemPassword = systemPassword.Value
+
+		bannedPIDs := types.NewList[*types.PID]()
+		bannedPIDs.Type = types.NewPID(0)
+		err = bannedPIDs.ExtractFrom(stream)
+		if err != nil {
+			return fmt.Errorf("Failed to read banned PIDs of GID %d. %s", gatheringID, err.Error())
+		}
+
+		session.BannedPIDs = bannedPIDs.Slice()
+
+		reservedSlots := types.NewList[*types.PrimitiveU32]()
+		reservedSlots.Type = types.NewPrimitiveU32(0)
+		err = reservedSlots.ExtractFrom(stream)
+		if err != nil {
+			return fmt.Errorf("Failed to read reserved slots of GID %d. %s", gatheringID, err.Error())
+		}
+
+		if reservedSlots.Length() != participants.Length() {
+			return fmt.Errorf("GID %d has %d reserved slot entries for %d participants", gatheringID, reservedSlots.Length(), participants.Length())
+		}
+
+		spectators := types.NewList[*types.PID]()
+		spectators.Type = types.NewPID(0)
+		err = spectators.ExtractFrom(stream)
+		if err != nil {
+			return fmt.Errorf("Failed to read spectators of GID %d. %s", gatheringID, err.Error())
+		}
+
+		sessions[gatheringID] = session
+
+		members := make([]*pendingMember, 0, participants.Length()+spectators.Length())
+		participants.Each(func(i int, pid *types.PID) bool {
+			slots, _ := reservedSlots.Get(i)
+			members = append(members, &pendingMember{pid: pid, role: SessionMemberRoles.Participant, reservedSlots: int(slots.Value)})
+
+			return false
+		})
+
+		spectators.Each(func(_ int, pid *types.PID) bool {
+			members = append(members, &pendingMember{pid: pid, role: SessionMemberRoles.Spectator})
+			return false
+		})
+
+		if len(members) != 0 {
+			pendingMembers[gatheringID] = members
+			pendingMemberCount += int32(len(members))
+		}
+
+		if gatheringID > currentGatheringID {
+			currentGatheringID = gatheringID
+		}
+	}
+
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	mm.sessions.Clear()
+	for gatheringID, session := range sessions {
+		mm.sessions.Set(gatheringID, session)
+	}
+
+	mm.pendingMembers = pendingMembers
+	mm.pendingMemberCount.Store(pendingMemberCount)
+
+	if currentGatheringID > mm.CurrentGatheringID.Value {
+		mm.CurrentGatheringID.Value = currentGatheringID
+	}
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("Restored %d sessions with %d members", len(sessions), pendingMemberCount)
+	}
+
+	return nil
+}
+
+// RestoreSnapshotFromBackend loads the snapshot stored in the backend and restores it. Does nothing if there is no snapshot.
+//
+// If gracePeriod is not zero, the members which haven't reconnected after it are dropped, see ExpirePendingParticipants
+func (mm *MatchmakingManager) RestoreSnapshotFromBackend(backend SnapshotBackend, gracePeriod time.Duration) error {
+	data, err := backend.LoadSnapshot()
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	err = mm.RestoreSnapshot(data)
+	if err != nil {
+		return err
+	}
+
+	if gracePeriod != 0 {
+		time.AfterFunc(gracePeriod, mm.ExpirePendingParticipants)
+	}
+
+	return nil
+}
+
+// RestoreConnection adds a connection back to every restored session its PID was a participant or spectator of
+func (mm *MatchmakingManager) RestoreConnection(connection *nex.PRUDPConnection) {
+	// * Avoid taking the lock on every packet once everyone has reconnected
+	if mm.pendingMemberCount.Load() == 0 {
+		return
+	}
+
+	pid := connection.PID()
+	if pid == nil || pid.Value() == 0 {
+		return
+	}
+
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	for gatheringID, members := range mm.pendingMembers {
+		index := slices.IndexFunc(members, func(member *pendingMember) bool {
+			return member.pid.Equals(pid)
+		})
+
+		if index == -1 {
+			continue
+		}
+
+		member := members[index]
+		mm.pendingMemberCount.Add(-1)
+
+		if len(members) == 1 {
+			delete(mm.pendingMembers, gatheringID)
+		} else {
+			mm.pendingMembers[gatheringID] = slices.Delete(members, index, index+1)
+		}
+
+		session, ok := mm.sessions.Get(gatheringID)
+		if !ok {
+			continue
+		}
+
+		if member.role == SessionMemberRoles.Spectator {
+			session.SpectatorConnectionIDs.Add(connection.ID)
+
+			if mm.SessionManagementDebugLog {
+				globals.Logger.Infof("GID %d: Restored spectator PID %d", gatheringID, pid.Value())
+			}
+
+			mm.emitSessionEventImpl(SessionEventTypes.SpectatorJoined, session, connection, SessionEventReasons.None)
+			continue
+		}
+
+		session.ConnectionIDs.Add(connection.ID)
+		session.reserveSlots(connection.ID, member.reservedSlots)
+
+		if mm.SessionManagementDebugLog {
+			globals.Logger.Infof("GID %d: Restored PID %d", gatheringID, pid.Value())
+		}
+
+		for _, handler := range mm.onPlayerJoinSessionHandlers {
+			handler(gatheringID, connection.ID)
+		}
+
+		mm.emitSessionEventImpl(SessionEventTypes.PlayerJoined, session, connection, SessionEventReasons.None)
+	}
+}
+
+// ExpirePendingParticipants forgets the restored participants and spectators which haven't reconnected yet.
+// The restored sessions left without any participant are removed
+func (mm *MatchmakingManager) ExpirePendingParticipants() {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	for gatheringID := range mm.pendingMembers {
+		session, ok := mm.sessions.Get(gatheringID)
+		if !ok {
+			continue
+		}
+
+		if session.ConnectionIDs.Size() == 0 {
+			mm.removeSessionImpl(nil, gatheringID, SessionEventReasons.Expired)
+		} else {
+			session.GameMatchmakeSession.ParticipationCount.Value = uint32(mm.sessionParticipantCountImpl(session))
+		}
+	}
+
+	mm.pendingMembers = make(map[uint32][]*pendingMember)
+	mm.pendingMemberCount.Store(0)
+}
@@ -0,0 +1,157 @@
+package common_globals
+
+import (
+	"fmt"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
+)
+
+// SessionMemberRole is the role of a connection in a session
+type SessionMemberRole uint8
+
+type sessionMemberRoles struct {
+	None        SessionMemberRole // * The connection isn't a member of the session
+	Participant SessionMemberRole
+	Spectator   SessionMemberRole
+}
+
+// SessionMemberRoles is an enum of the possible roles of a connection in a session
+var SessionMemberRoles = sessionMemberRoles{
+	None:        0,
+	Participant: 1,
+	Spectator:   2,
+}
+
+// String returns the name of the role
+func (smr SessionMemberRole) String() string {
+	switch smr {
+	case SessionMemberRoles.Participant:
+		return "participant"
+	case SessionMemberRoles.Spectator:
+		return "spectator"
+	default:
+		return "none"
+	}
+}
+
+// MemberRole returns the role of the connection in the session
+func (cms *CommonMatchmakeSession) MemberRole(connectionID uint32) SessionMemberRole {
+	if cms.ConnectionIDs.Has(connectionID) {
+		return SessionMemberRoles.Participant
+	}
+
+	if cms.SpectatorConnectionIDs.Has(connectionID) {
+		return SessionMemberRoles.Spectator
+	}
+
+	return SessionMemberRoles.None
+}
+
+// findSpectatorImpl returns the connection of the spectator of the session with the given PID, or nil if it isn't there
+func (mm *MatchmakingManager) findSpectatorImpl(session *CommonMatchmakeSession, pid *types.PID) *nex.PRUDPConnection {
+	var spectator *nex.PRUDPConnection
+	session.SpectatorConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		connection := mm.Endpoint.FindConnectionByID(connectionID)
+		if connection != nil && connection.PID().Equals(pid) {
+			spectator = connection
+			return true
+		}
+
+		return false
+	})
+
+	return spectator
+}
+
+// AddSpectatorToSession adds the connection to the session as a spectator.
+//
+// Spectators receive the notifications sent to the whole session, but they don't use a slot and
+// can't become the owner or host. The participants aren't notified, as clients only know about participants.
+// The session must be checked with VerifySessionJoin beforehand if spectators have to follow the same rules as participants.
+// Returns a NEX error code if failed
+func (mm *MatchmakingManager) AddSpectatorToSession(session *CommonMatchmakeSession, connection *nex.PRUDPConnection) *nex.Error {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	gatheringID := session.GameMatchmakeSession.Gathering.ID.Value
+
+	// * TOCTOU, just in case
+	_, ok := mm.sessions.Get(gatheringID)
+	if !ok {
+		return nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	if isBannedFromSessionImpl(connection, session) {
+		return nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	if session.MemberRole(connection.ID) != SessionMemberRoles.None {
+		return nex.NewError(nex.ResultCodes.RendezVous.AlreadyParticipatedGathering, fmt.Sprintf("Connection ID %d is already in gathering %d", connection.ID, gatheringID))
+	}
+
+	session.SpectatorConnectionIDs.Add(connection.ID)
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Added spectator PID %d", gatheringID, connection.PID().Value())
+	}
+
+	mm.emitSessionEventImpl(SessionEventTypes.SpectatorJoined, session, connection, SessionEventReasons.None)
+
+	return nil
+}
+
+// removeSpectatorFromSessionImpl removes a spectator from the session. The session is kept even if it was the last member
+func (mm *MatchmakingManager) removeSpectatorFromSessionImpl(connection *nex.PRUDPConnection, session *CommonMatchmakeSession, reason SessionEventReason) {
+	session.SpectatorConnectionIDs.DeleteAll(connection.ID)
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Removed spectator PID %d", session.GameMatchmakeSession.Gathering.ID.Value, connection.PID().Value())
+	}
+
+	mm.emitSessionEventImpl(SessionEventTypes.SpectatorLeft, session, connection, reason)
+}
+
+// removeSpectatorFromAllSessionsImpl removes a connection from every session it is spectating
+func (mm *MatchmakingManager) removeSpectatorFromAllSessionsImpl(connection *nex.PRUDPConnection, reason SessionEventReason) {
+	spectatedSessions := make([]*CommonMatchmakeSession, 0)
+	mm.sessions.Each(func(_ uint32, session *CommonMatchmakeSession) bool {
+		if session.SpectatorConnectionIDs.Has(connection.ID) {
+			spectatedSessions = append(spectatedSessions, session)
+		}
+
+		return false
+	})
+
+	for _, session := range spectatedSessions {
+		mm.removeSpectatorFromSessionImpl(connection, session, reason)
+	}
+}
+
+// GetSessionSpectators returns the connections of the spectators of the session.
+// Spectators which can't be found on the endpoint are left out.
+// Returns a NEX error code if the connection isn't allowed to see them
+func (mm *MatchmakingManager) GetSessionSpectators(connection *nex.PRUDPConnection, session *CommonMatchmakeSession) ([]*nex.PRUDPConnection, *nex.Error) {
+	mm.sessionsMutex.RLock()
+	defer mm.sessionsMutex.RUnlock()
+
+	if !mm.canViewSessionImpl(connection, session) {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	spectators := make([]*nex.PRUDPConnection, 0, session.SpectatorConnectionIDs.Size())
+	session.SpectatorConnectionIDs.Each(func(_ int, connectionID uint32) bool {
+		spectator := mm.Endpoint.FindConnectionByID(connectionID)
+		if spectator == nil {
+			Logger.Warning("Spectator not found")
+			return false
+		}
+
+		spectators = append(spectators, spectator)
+
+		return false
+	})
+
+	return spectators, nil
+}
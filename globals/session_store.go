@@ -0,0 +1,80 @@
+package common_globals
+
+// SessionStore is the storage backend which holds the matchmaking sessions, indexed by their gathering ID.
+//
+// Implementations do not need to be safe for concurrent use. Every method is called with the sessions
+// mutex already held: read locked for Get, Each and Len, and write locked for Set, Delete and Clear.
+// For the same reason, implementations must never call back into the session management functions,
+// as that would deadlock.
+//
+// The sessions returned by a store are live objects. Mutating them mutates the stored session, so a store
+// must hand back the same pointer it was given in Set rather than a copy.
+type SessionStore interface {
+	// Get returns the session with the given gathering ID, if it exists
+	Get(gatheringID uint32) (*CommonMatchmakeSession, bool)
+
+	// Set stores a session under the given gathering ID, replacing any existing session
+	Set(gatheringID uint32, session *CommonMatchmakeSession)
+
+	// Delete removes the session with the given gathering ID. Does nothing if it doesn't exist
+	Delete(gatheringID uint32)
+
+	// Each runs a callback for every session until it returns true.
+	// Returns true if the callback returned true. The iteration order is unspecified
+	Each(callback func(gatheringID uint32, session *CommonMatchmakeSession) bool) bool
+
+	// Len returns the number of stored sessions
+	Len() int
+
+	// Clear removes every session
+	Clear()
+}
+
+// MemorySessionStore is the default SessionStore, which keeps the sessions in a map
+type MemorySessionStore struct {
+	sessions map[uint32]*CommonMatchmakeSession
+}
+
+// Get returns the session with the given gathering ID, if it exists
+func (mss *MemorySessionStore) Get(gatheringID uint32) (*CommonMatchmakeSession, bool) {
+	session, ok := mss.sessions[gatheringID]
+	return session, ok
+}
+
+// Set stores a session under the given gathering ID, replacing any existing session
+func (mss *MemorySessionStore) Set(gatheringID uint32, session *CommonMatchmakeSession) {
+	mss.sessions[gatheringID] = session
+}
+
+// Delete removes the session with the given gathering ID. Does nothing if it doesn't exist
+func (mss *MemorySessionStore) Delete(gatheringID uint32) {
+	delete(mss.sessions, gatheringID)
+}
+
+// Each runs a callback for every session until it returns true
+func (mss *MemorySessionStore) Each(callback func(gatheringID uint32, session *CommonMatchmakeSession) bool) bool {
+	for gatheringID, session := range mss.sessions {
+		if callback(gatheringID, session) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Len returns the number of stored sessions
+func (mss *MemorySessionStore) Len() int {
+	return len(mss.sessions)
+}
+
+// Clear removes every session
+func (mss *MemorySessionStore) Clear() {
+	mss.sessions = make(map[uint32]*CommonMatchmakeSession)
+}
+
+// NewMemorySessionStore returns a new, empty MemorySessionStore
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[uint32]*CommonMatchmakeSession),
+	}
+}
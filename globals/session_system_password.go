@@ -0,0 +1,98 @@
+package common_globals
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	"github.com/PretendoNetwork/nex-protocols-go/v2/globals"
+	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
+)
+
+// ownedSessionImpl returns the session with the given gathering ID if the connection owns it.
+// Returns a NEX error code if the session doesn't exist or is owned by someone else
+func (mm *MatchmakingManager) ownedSessionImpl(connection *nex.PRUDPConnection, gatheringID uint32) (*CommonMatchmakeSession, *nex.Error) {
+	session, ok := mm.sessions.Get(gatheringID)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	if !session.GameMatchmakeSession.Gathering.OwnerPID.Equals(connection.PID()) {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	return session, nil
+}
+
+// GenerateSessionSystemPassword sets a new random system password on a session owned by the connection,
+// and sends it to the participants with a SystemPasswordChanged notification.
+// Returns the new password, or a NEX error code if failed
+func (mm *MatchmakingManager) GenerateSessionSystemPassword(connection *nex.PRUDPConnection, gatheringID uint32) (string, *nex.Error) {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	session, errCode := mm.ownedSessionImpl(connection, gatheringID)
+	if errCode != nil {
+		return "", errCode
+	}
+
+	passwordBytes := make([]byte, 8)
+	_, err := rand.Read(passwordBytes)
+	if err != nil {
+		Logger.Error(err.Error())
+		return "", nex.NewError(nex.ResultCodes.Core.Unknown, "change_error")
+	}
+
+	session.SystemPassword = hex.EncodeToString(passwordBytes)
+	session.GameMatchmakeSession.SystemPasswordEnabled = types.NewPrimitiveBool(true)
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Generated a system password", gatheringID)
+	}
+
+	category := notifications.NotificationCategories.SystemPasswordChanged
+	subtype := notifications.NotificationSubTypes.SystemPasswordChanged.None
+
+	oEvent := NewNotificationEvent()
+	oEvent.PIDSource = connection.PID()
+	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gatheringID))
+	oEvent.StrParam = types.NewString(session.SystemPassword)
+
+	logNotificationDeliveryFailures(mm.sendNotificationEventToSessionImpl(session, oEvent))
+
+	return session.SystemPassword, nil
+}
+
+// ClearSessionSystemPassword removes the system password of a session owned by the connection,
+// and notifies the participants with a SystemPasswordCleared notification.
+// Returns a NEX error code if failed
+func (mm *MatchmakingManager) ClearSessionSystemPassword(connection *nex.PRUDPConnection, gatheringID uint32) *nex.Error {
+	mm.sessionsMutex.Lock()
+	defer mm.sessionsMutex.Unlock()
+
+	session, errCode := mm.ownedSessionImpl(connection, gatheringID)
+	if errCode != nil {
+		return errCode
+	}
+
+	session.SystemPassword = ""
+	session.GameMatchmakeSession.SystemPasswordEnabled = types.NewPrimitiveBool(false)
+
+	if mm.SessionManagementDebugLog {
+		globals.Logger.Infof("GID %d: Cleared the system password", gatheringID)
+	}
+
+	category := notifications.NotificationCategories.SystemPasswordCleared
+	subtype := notifications.NotificationSubTypes.SystemPasswordCleared.None
+
+	oEvent := NewNotificationEvent()
+	oEvent.PIDSource = connection.PID()
+	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gatheringID))
+
+	logNotificationDeliveryFailures(mm.sendNotificationEventToSessionImpl(session, oEvent))
+
+	return nil
+}
@@ -0,0 +1,542 @@
+package common_globals
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// directoryMessage is a request or a result exchanged between a TCPSessionDirectory and a TCPSessionDirectoryServer.
+// Messages are sent as JSON, one per line
+type directoryMessage struct {
+	ID                  uint64   `json:"id"`
+	Operation           string   `json:"operation"`
+	InstanceID          string   `json:"instance_id,omitempty"`
+	FromInstanceID      string   `json:"from_instance_id,omitempty"`
+	GatheringID         uint32   `json:"gathering_id,omitempty"`
+	PID                 uint64   `json:"pid,omitempty"`
+	Message             string   `json:"message,omitempty"`
+	DontCareMyBlockList bool     `json:"dont_care_my_block_list,omitempty"`
+	UserPassword        string   `json:"user_password,omitempty"`
+	SystemPassword      string   `json:"system_password,omitempty"`
+	ParticipationCount  uint16   `json:"participation_count,omitempty"`
+	Gracefully          bool     `json:"gracefully,omitempty"`
+	Data                []byte   `json:"data,omitempty"`     // * Encoded DirectorySession, or MatchmakeSession for joins
+	Sessions            [][]byte `json:"sessions,omitempty"` // * Encoded DirectorySessions
+	Error               string   `json:"error,omitempty"`
+	ResultCode          uint32   `json:"result_code,omitempty"`
+}
+
+const directoryResultOperation = "result"
+
+// directoryCallTimeout is how long a request waits for its result. Joins forwarded by the server
+// wait for the instance holding the session, so this also bounds how long a hanging instance blocks an RMC handler
+const directoryCallTimeout = 10 * time.Second
+
+// directoryConnection sends requests over a TCP connection and matches them with their results.
+// Requests coming from the other side are answered by handleRequest
+type directoryConnection struct {
+	connection    net.Conn
+	writeMutex    sync.Mutex
+	encoder       *json.Encoder
+	pendingMutex  sync.Mutex
+	pending       map[uint64]chan *directoryMessage
+	nextID        uint64
+	closed        bool
+	handleRequest func(request *directoryMessage) *directoryMessage
+}
+
+func newDirectoryConnection(connection net.Conn, handleRequest func(request *directoryMessage) *directoryMessage) *directoryConnection {
+	return &directoryConnection{
+		connection:    connection,
+		encoder:       json.NewEncoder(connection),
+		pending:       make(map[uint64]chan *directoryMessage),
+		handleRequest: handleRequest,
+	}
+}
+
+func (dc *directoryConnection) send(message *directoryMessage) error {
+	dc.writeMutex.Lock()
+	defer dc.writeMutex.Unlock()
+
+	return dc.encoder.Encode(message)
+}
+
+// call sends a request and waits for its result, for at most directoryCallTimeout
+func (dc *directoryConnection) call(request *directoryMessage) (*directoryMessage, error) {
+	result := make(chan *directoryMessage, 1)
+
+	dc.pendingMutex.Lock()
+	if dc.closed {
+		dc.pendingMutex.Unlock()
+		return nil, errors.New("Session directory connection is closed")
+	}
+
+	dc.nextID++
+	request.ID = dc.nextID
+	dc.pending[request.ID] = result
+	dc.pendingMutex.Unlock()
+
+	err := dc.send(request)
+	if err != nil {
+		dc.pendingMutex.Lock()
+		delete(dc.pending, request.ID)
+		dc.pendingMutex.Unlock()
+
+		return nil, err
+	}
+
+	timer := time.NewTimer(directoryCallTimeout)
+	defer timer.Stop()
+
+	var response *directoryMessage
+	var ok bool
+
+	select {
+	case response, ok = <-result:
+		if !ok {
+			return nil, errors.New("Session directory connection is closed")
+		}
+	case <-timer.C:
+		// * The result may still arrive later, it is dropped as the request isn't pending anymore
+		dc.pendingMutex.Lock()
+		delete(dc.pending, request.ID)
+		dc.pendingMutex.Unlock()
+
+		return nil, fmt.Errorf("Session directory %s request %d timed out", request.Operation, request.ID)
+	}
+
+	if response.Error != "" {
+		return response, errors.New(response.Error)
+	}
+
+	return response, nil
+}
+
+// serve reads the incoming messages until the connection is closed. Requests are answered in their own goroutine
+func (dc *directoryConnection) serve() {
+	scanner := bufio.NewScanner(dc.connection)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		message := &directoryMessage{}
+		err := json.Unmarshal(scanner.Bytes(), message)
+		if err != nil {
+			Logger.Error(err.Error())
+			continue
+		}
+
+		if message.Operation != directoryResultOperation {
+			go func() {
+				result := dc.handleRequest(message)
+				result.ID = message.ID
+				result.Operation = directoryResultOperation
+
+				err := dc.send(result)
+				if err != nil {
+					Logger.Error(err.Error())
+				}
+			}()
+
+			continue
+		}
+
+		dc.pendingMutex.Lock()
+		pending, ok := dc.pending[message.ID]
+		delete(dc.pending, message.ID)
+		dc.pendingMutex.Unlock()
+
+		if ok {
+			pending <- message
+		}
+	}
+
+	dc.connection.Close()
+
+	dc.pendingMutex.Lock()
+	dc.closed = true
+	for id, pending := range dc.pending {
+		close(pending)
+		delete(dc.pending, id)
+	}
+	dc.pendingMutex.Unlock()
+}
+
+// directoryErrorResult returns the result of a failed request
+func directoryErrorResult(err error) *directoryMessage {
+	return &directoryMessage{Error: err.Error()}
+}
+
+// directoryJoinResult returns the result of a join request
+func directoryJoinResult(data []byte, errCode *nex.Error) *directoryMessage {
+	if errCode != nil {
+		return &directoryMessage{Error: errCode.Message, ResultCode: errCode.ResultCode}
+	}
+
+	return &directoryMessage{Data: data}
+}
+
+// TCPSessionDirectoryServer holds the sessions published by TCPSessionDirectory clients, and routes
+// the joins and leaves between them. It is meant as a reference implementation, and to run several
+// instances on a single machine.
+//
+// The server doesn't do any authentication, so it must only listen on a trusted network
+type TCPSessionDirectoryServer struct {
+	mutex     sync.RWMutex
+	listener  net.Listener
+	sessions  map[string]map[uint32][]byte // * Encoded DirectorySessions, keyed by instance ID, then by gathering ID
+	instances map[string]*directoryConnection
+}
+
+// Listen starts listening on the given address, such as "127.0.0.1:0", and serves the clients in the background
+func (tsds *TCPSessionDirectoryServer) Listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	tsds.listener = listener
+
+	go func() {
+		for {
+			connection, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go tsds.serveConnection(connection)
+		}
+	}()
+
+	return nil
+}
+
+// Addr returns the address the server is listening on
+func (tsds *TCPSessionDirectoryServer) Addr() net.Addr {
+	return tsds.listener.Addr()
+}
+
+// Close stops listening. The clients which are already connected are kept
+func (tsds *TCPSessionDirectoryServer) Close() error {
+	return tsds.listener.Close()
+}
+
+func (tsds *TCPSessionDirectoryServer) serveConnection(connection net.Conn) {
+	var instanceID string
+	var dc *directoryConnection
+
+	dc = newDirectoryConnection(connection, func(request *directoryMessage) *directoryMessage {
+		switch request.Operation {
+		case "register":
+			tsds.mutex.Lock()
+			defer tsds.mutex.Unlock()
+
+			if _, ok := tsds.instances[request.InstanceID]; ok {
+				return directoryErrorResult(fmt.Errorf("Instance %s is already registered", request.InstanceID))
+			}
+
+			instanceID = request.InstanceID
+			tsds.instances[instanceID] = dc
+			tsds.sessions[instanceID] = make(map[uint32][]byte)
+
+			return &directoryMessage{}
+		case "unregister":
+			tsds.removeInstance(request.InstanceID, dc)
+
+			return &directoryMessage{}
+		case "publish":
+			tsds.mutex.Lock()
+			defer tsds.mutex.Unlock()
+
+			sessions, ok := tsds.sessions[request.InstanceID]
+			if !ok {
+				return directoryErrorResult(fmt.Errorf("Instance %s is not registered", request.InstanceID))
+			}
+
+			for otherInstanceID, otherSessions := range tsds.sessions {
+				if _, ok := otherSessions[request.GatheringID]; ok && otherInstanceID != request.InstanceID {
+					return directoryErrorResult(fmt.Errorf("GID %d is already published by instance %s", request.GatheringID, otherInstanceID))
+				}
+			}
+
+			sessions[request.GatheringID] = request.Data
+
+			return &directoryMessage{}
+		case "withdraw":
+			tsds.mutex.Lock()
+			defer tsds.mutex.Unlock()
+
+			delete(tsds.sessions[request.InstanceID], request.GatheringID)
+
+			return &directoryMessage{}
+		case "list":
+			tsds.mutex.RLock()
+			defer tsds.mutex.RUnlock()
+
+			result := &directoryMessage{Sessions: make([][]byte, 0)}
+			for _, sessions := range tsds.sessions {
+				for _, data := range sessions {
+					result.Sessions = append(result.Sessions, data)
+				}
+			}
+
+			return result
+		case "join", "leave", "unregistered":
+			tsds.mutex.RLock()
+			instance, ok := tsds.instances[request.InstanceID]
+			tsds.mutex.RUnlock()
+
+			if !ok {
+				return &directoryMessage{Error: "change_error", ResultCode: nex.ResultCodes.RendezVous.SessionVoid}
+			}
+
+			// * Forward the request to the instance which holds the session
+			forwarded := *request
+			result, err := instance.call(&forwarded)
+			if result == nil {
+				return directoryErrorResult(err)
+			}
+
+			return result
+		default:
+			return directoryErrorResult(fmt.Errorf("Unknown session directory operation %s", request.Operation))
+		}
+	})
+
+	dc.serve()
+
+	// * The sessions of a disconnected instance can't be joined anymore
+	tsds.mutex.RLock()
+	registeredInstanceID := instanceID
+	tsds.mutex.RUnlock()
+
+	if registeredInstanceID != "" {
+		tsds.removeInstance(registeredInstanceID, dc)
+	}
+}
+
+// removeInstance withdraws the sessions of the instance registered by the connection, and tells the other instances
+func (tsds *TCPSessionDirectoryServer) removeInstance(instanceID string, dc *directoryConnection) {
+	tsds.mutex.Lock()
+	if tsds.instances[instanceID] != dc {
+		tsds.mutex.Unlock()
+		return
+	}
+
+	delete(tsds.instances, instanceID)
+	delete(tsds.sessions, instanceID)
+
+	instances := make([]*directoryConnection, 0, len(tsds.instances))
+	for _, instance := range tsds.instances {
+		instances = append(instances, instance)
+	}
+	tsds.mutex.Unlock()
+
+	for _, instance := range instances {
+		go func(instance *directoryConnection) {
+			_, err := instance.call(&directoryMessage{Operation: "instance_removed", InstanceID: instanceID})
+			if err != nil {
+				Logger.Error(err.Error())
+			}
+		}(instance)
+	}
+}
+
+// NewTCPSessionDirectoryServer returns a new TCPSessionDirectoryServer. Call Listen to start it
+func NewTCPSessionDirectoryServer() *TCPSessionDirectoryServer {
+	return &TCPSessionDirectoryServer{
+		sessions:  make(map[string]map[uint32][]byte),
+		instances: make(map[string]*directoryConnection),
+	}
+}
+
+// TCPSessionDirectory is a SessionDirectory backed by a TCPSessionDirectoryServer.
+// Each instance must use its own TCPSessionDirectory
+type TCPSessionDirectory struct {
+	connection      *directoryConnection
+	libraryVersions *nex.LibraryVersions
+	instance        DirectoryInstance
+}
+
+func (tsd *TCPSessionDirectory) handleRequest(request *directoryMessage) *directoryMessage {
+	if tsd.instance == nil {
+		return directoryErrorResult(errors.New("No instance is registered"))
+	}
+
+	switch request.Operation {
+	case "join":
+		options := SessionJoinOptions{
+			DontCareMyBlockList: request.DontCareMyBlockList,
+			UserPassword:        request.UserPassword,
+			SystemPassword:      request.SystemPassword,
+			ParticipationCount:  request.ParticipationCount,
+		}
+
+		matchmakeSession, errCode := tsd.instance.JoinFromRemote(request.FromInstanceID, request.GatheringID, types.NewPID(request.PID), request.Message, options)
+		if errCode != nil {
+			return directoryJoinResult(nil, errCode)
+		}
+
+		stream := nex.NewByteStreamOut(tsd.libraryVersions, snapshotStreamSettings())
+		matchmakeSession.WriteTo(stream)
+
+		return directoryJoinResult(stream.Bytes(), nil)
+	case "leave":
+		tsd.instance.LeaveFromRemote(request.GatheringID, types.NewPID(request.PID), request.Gracefully)
+
+		return &directoryMessage{}
+	case "unregistered":
+		tsd.instance.UnregisteredFromRemote(request.GatheringID, types.NewPID(request.PID))
+
+		return &directoryMessage{}
+	case "instance_removed":
+		tsd.instance.InstanceRemoved(request.InstanceID)
+
+		return &directoryMessage{}
+	default:
+		return directoryErrorResult(fmt.Errorf("Unknown session directory operation %s", request.Operation))
+	}
+}
+
+// RegisterInstance implements SessionDirectory
+func (tsd *TCPSessionDirectory) RegisterInstance(instanceID string, instance DirectoryInstance) error {
+	tsd.instance = instance
+
+	_, err := tsd.connection.call(&directoryMessage{Operation: "register", InstanceID: instanceID})
+
+	return err
+}
+
+// UnregisterInstance implements SessionDirectory
+func (tsd *TCPSessionDirectory) UnregisterInstance(instanceID string) error {
+	_, err := tsd.connection.call(&directoryMessage{Operation: "unregister", InstanceID: instanceID})
+
+	return err
+}
+
+// Publish implements SessionDirectory
+func (tsd *TCPSessionDirectory) Publish(session *DirectorySession) error {
+	_, err := tsd.connection.call(&directoryMessage{
+		Operation:   "publish",
+		InstanceID:  session.InstanceID,
+		GatheringID: session.GatheringID,
+		Data:        encodeDirectorySession(session, tsd.libraryVersions),
+	})
+
+	return err
+}
+
+// Withdraw implements SessionDirectory
+func (tsd *TCPSessionDirectory) Withdraw(instanceID string, gatheringID uint32) error {
+	_, err := tsd.connection.call(&directoryMessage{Operation: "withdraw", InstanceID: instanceID, GatheringID: gatheringID})
+
+	return err
+}
+
+// List implements SessionDirectory
+func (tsd *TCPSessionDirectory) List() ([]*DirectorySession, error) {
+	result, err := tsd.connection.call(&directoryMessage{Operation: "list"})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*DirectorySession, 0, len(result.Sessions))
+	for _, data := range result.Sessions {
+		session, err := decodeDirectorySession(data, tsd.libraryVersions)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// Join implements SessionDirectory
+func (tsd *TCPSessionDirectory) Join(fromInstanceID string, instanceID string, gatheringID uint32, pid *types.PID, message string, options SessionJoinOptions) (*match_making_types.MatchmakeSession, *nex.Error) {
+	result, err := tsd.connection.call(&directoryMessage{
+		Operation:           "join",
+		InstanceID:          instanceID,
+		FromInstanceID:      fromInstanceID,
+		GatheringID:         gatheringID,
+		PID:                 pid.Value(),
+		Message:             message,
+		DontCareMyBlockList: options.DontCareMyBlockList,
+		UserPassword:        options.UserPassword,
+		SystemPassword:      options.SystemPassword,
+		ParticipationCount:  options.ParticipationCount,
+	})
+
+	if result != nil && result.ResultCode != 0 {
+		return nil, nex.NewError(result.ResultCode, result.Error)
+	}
+
+	if err != nil {
+		Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.Exception, "change_error")
+	}
+
+	matchmakeSession := match_making_types.NewMatchmakeSession()
+	err = matchmakeSession.ExtractFrom(nex.NewByteStreamIn(result.Data, tsd.libraryVersions, snapshotStreamSettings()))
+	if err != nil {
+		Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.Exception, "change_error")
+	}
+
+	return matchmakeSession, nil
+}
+
+// Leave implements SessionDirectory
+func (tsd *TCPSessionDirectory) Leave(instanceID string, gatheringID uint32, pid *types.PID, gracefully bool) error {
+	_, err := tsd.connection.call(&directoryMessage{
+		Operation:   "leave",
+		InstanceID:  instanceID,
+		GatheringID: gatheringID,
+		PID:         pid.Value(),
+		Gracefully:  gracefully,
+	})
+
+	return err
+}
+
+// Unregistered implements SessionDirectory
+func (tsd *TCPSessionDirectory) Unregistered(instanceID string, gatheringID uint32, pid *types.PID) error {
+	_, err := tsd.connection.call(&directoryMessage{
+		Operation:   "unregistered",
+		InstanceID:  instanceID,
+		GatheringID: gatheringID,
+		PID:         pid.Value(),
+	})
+
+	return err
+}
+
+// Close disconnects from the server, which withdraws every session of the instance
+func (tsd *TCPSessionDirectory) Close() error {
+	return tsd.connection.connection.Close()
+}
+
+// NewTCPSessionDirectory connects to a TCPSessionDirectoryServer. The library versions must be the ones
+// of the endpoint, and must be the same on every instance
+func NewTCPSessionDirectory(address string, libraryVersions *nex.LibraryVersions) (*TCPSessionDirectory, error) {
+	connection, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	tsd := &TCPSessionDirectory{libraryVersions: libraryVersions}
+	tsd.connection = newDirectoryConnection(connection, tsd.handleRequest)
+
+	go tsd.connection.serve()
+
+	return tsd, nil
+}
@@ -13,15 +13,15 @@ func (commonProtocol *CommonProtocol) endParticipation(err error, packet nex.Pac
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(idGathering.Value)
-	if !ok {
-		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
-	}
-
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
 
-	common_globals.RemoveConnectionIDFromSession(connection, session.GameMatchmakeSession.ID.Value, true)
+	session, ok := commonProtocol.manager.GetSession(idGathering.Value)
+	if ok {
+		commonProtocol.manager.RemoveConnectionIDFromSession(connection, session.GameMatchmakeSession.ID.Value, true)
+	} else if !commonProtocol.manager.LeaveRemoteSession(connection, idGathering.Value, true) {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
 
 	retval := types.NewPrimitiveBool(true)
 
@@ -3,23 +3,26 @@ package match_making_ext
 import (
 	"github.com/PretendoNetwork/nex-go/v2"
 	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	match_making_ext "github.com/PretendoNetwork/nex-protocols-go/v2/match-making-ext"
 )
 
 type CommonProtocol struct {
 	endpoint                nex.EndpointInterface
 	protocol                match_making_ext.Interface
+	manager                 *common_globals.MatchmakingManager
 	OnAfterEndParticipation func(acket nex.PacketInterface, idGathering *types.PrimitiveU32, strMessage *types.String)
 }
 
 // NewCommonProtocol returns a new CommonProtocol
-func NewCommonProtocol(protocol match_making_ext.Interface) *CommonProtocol {
+func NewCommonProtocol(protocol match_making_ext.Interface, manager *common_globals.MatchmakingManager) *CommonProtocol {
 	commonProtocol := &CommonProtocol{
 		endpoint: protocol.Endpoint(),
 		protocol: protocol,
+		manager:  manager,
 	}
 
-	protocol.SetHandlerEndParticipation(commonProtocol.endParticipation)
+	protocol.SetHandlerEndParticipation(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.endParticipation))
 
 	return commonProtocol
 }
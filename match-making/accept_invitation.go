@@ -0,0 +1,48 @@
+package matchmaking
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
+)
+
+func (commonProtocol *CommonProtocol) acceptInvitation(err error, packet nex.PacketInterface, callID uint32, idGathering *types.PrimitiveU32, strMessage *types.String) (*nex.RMCMessage, *nex.Error) {
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	session, ok := commonProtocol.manager.GetSession(idGathering.Value)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	// * The guest joins the session on their own afterwards
+	errCode := commonProtocol.manager.AcceptSessionInvitation(session, connection, strMessage.Value)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	retval := types.NewPrimitiveBool(true)
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	retval.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = match_making.ProtocolID
+	rmcResponse.MethodID = match_making.MethodAcceptInvitation
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterAcceptInvitation != nil {
+		go commonProtocol.OnAfterAcceptInvitation(packet, idGathering, strMessage)
+	}
+
+	return rmcResponse, nil
+}
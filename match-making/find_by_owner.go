@@ -0,0 +1,58 @@
+package matchmaking
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
+)
+
+func (commonProtocol *CommonProtocol) findByOwner(err error, packet nex.PacketInterface, callID uint32, id *types.PID, resultRange *types.ResultRange) (*nex.RMCMessage, *nex.Error) {
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	sessions := commonProtocol.manager.FindSessionsByOwner(connection, id)
+
+	offset := int(resultRange.Offset.Value)
+	if offset > len(sessions) {
+		offset = len(sessions)
+	}
+
+	sessions = sessions[offset:]
+	if len(sessions) > int(resultRange.Length.Value) {
+		sessions = sessions[:resultRange.Length.Value]
+	}
+
+	lstGathering := types.NewList[*types.AnyDataHolder]()
+	lstGathering.Type = types.NewAnyDataHolder()
+
+	for _, session := range sessions {
+		matchmakeSessionDataHolder := types.NewAnyDataHolder()
+		matchmakeSessionDataHolder.TypeName = types.NewString("MatchmakeSession")
+		matchmakeSessionDataHolder.ObjectData = session.GameMatchmakeSession.Copy()
+
+		lstGathering.Append(matchmakeSessionDataHolder)
+	}
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	lstGathering.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = match_making.ProtocolID
+	rmcResponse.MethodID = match_making.MethodFindByOwner
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterFindByOwner != nil {
+		go commonProtocol.OnAfterFindByOwner(packet, id, resultRange)
+	}
+
+	return rmcResponse, nil
+}
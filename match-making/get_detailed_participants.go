@@ -0,0 +1,64 @@
+package matchmaking
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+func (commonProtocol *CommonProtocol) getDetailedParticipants(err error, packet nex.PacketInterface, callID uint32, idGathering *types.PrimitiveU32) (*nex.RMCMessage, *nex.Error) {
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	session, ok := commonProtocol.manager.GetSession(idGathering.Value)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	participants, errCode := commonProtocol.manager.GetSessionParticipants(connection, session)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	lstParticipants := types.NewList[*match_making_types.ParticipantDetails]()
+	lstParticipants.Type = match_making_types.NewParticipantDetails()
+
+	for _, participant := range participants {
+		participantDetails := match_making_types.NewParticipantDetails()
+		participantDetails.IDParticipant = participant.PID()
+
+		// * Names are left empty if there is no GetUserName handler
+		if commonProtocol.GetUserName != nil {
+			participantDetails.StrName = types.NewString(commonProtocol.GetUserName(participant.PID()))
+		}
+
+		// TODO - The join message isn't kept, so StrMessage is left empty
+		participantDetails.UIParticipants = types.NewPrimitiveU16(uint16(session.PartySize(participant.ID)))
+
+		lstParticipants.Append(participantDetails)
+	}
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	lstParticipants.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = match_making.ProtocolID
+	rmcResponse.MethodID = match_making.MethodGetDetailedParticipants
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterGetDetailedParticipants != nil {
+		go commonProtocol.OnAfterGetDetailedParticipants(packet, idGathering)
+	}
+
+	return rmcResponse, nil
+}
@@ -0,0 +1,40 @@
+package matchmaking
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+func (commonProtocol *CommonProtocol) getInvitationsReceived(err error, packet nex.PacketInterface, callID uint32) (*nex.RMCMessage, *nex.Error) {
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	lstInvitations := types.NewList[*match_making_types.Invitation]()
+	lstInvitations.Type = match_making_types.NewInvitation()
+	lstInvitations.SetFromData(commonProtocol.manager.GetInvitationsReceived(connection.PID()))
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	lstInvitations.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = match_making.ProtocolID
+	rmcResponse.MethodID = match_making.MethodGetInvitationsReceived
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterGetInvitationsReceived != nil {
+		go commonProtocol.OnAfterGetInvitationsReceived(packet)
+	}
+
+	return rmcResponse, nil
+}
@@ -0,0 +1,49 @@
+package matchmaking
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+func (commonProtocol *CommonProtocol) getInvitationsSent(err error, packet nex.PacketInterface, callID uint32, idGathering *types.PrimitiveU32) (*nex.RMCMessage, *nex.Error) {
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	session, ok := commonProtocol.manager.GetSession(idGathering.Value)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	if !session.GameMatchmakeSession.Gathering.OwnerPID.Equals(connection.PID()) {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	lstInvitations := types.NewList[*match_making_types.Invitation]()
+	lstInvitations.Type = match_making_types.NewInvitation()
+	lstInvitations.SetFromData(commonProtocol.manager.GetInvitationsSent(idGathering.Value))
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	lstInvitations.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = match_making.ProtocolID
+	rmcResponse.MethodID = match_making.MethodGetInvitationsSent
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterGetInvitationsSent != nil {
+		go commonProtocol.OnAfterGetInvitationsSent(packet, idGathering)
+	}
+
+	return rmcResponse, nil
+}
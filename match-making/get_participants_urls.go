@@ -0,0 +1,55 @@
+package matchmaking
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
+)
+
+func (commonProtocol *CommonProtocol) getParticipantsURLs(err error, packet nex.PacketInterface, callID uint32, idGathering *types.PrimitiveU32) (*nex.RMCMessage, *nex.Error) {
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	session, ok := commonProtocol.manager.GetSession(idGathering.Value)
+	if !ok {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	participants, errCode := commonProtocol.manager.GetSessionParticipants(connection, session)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	lstStationURLs := types.NewList[*types.StationURL]()
+	lstStationURLs.Type = types.NewStationURL("")
+
+	for _, participant := range participants {
+		participant.StationURLs.Each(func(_ int, stationURL *types.StationURL) bool {
+			lstStationURLs.Append(stationURL)
+			return false
+		})
+	}
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	lstStationURLs.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = match_making.ProtocolID
+	rmcResponse.MethodID = match_making.MethodGetParticipantsURLs
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterGetParticipantsURLs != nil {
+		go commonProtocol.OnAfterGetParticipantsURLs(packet, idGathering)
+	}
+
+	return rmcResponse, nil
+}
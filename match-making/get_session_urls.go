@@ -13,14 +13,20 @@ func (commonProtocol *CommonProtocol) getSessionURLs(err error, packet nex.Packe
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(gid.Value)
-	if !ok {
-		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
-	}
-
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
 
+	session, ok := commonProtocol.manager.GetSession(gid.Value)
+	if !ok {
+		// * The session may be held by another instance, which published the URLs of its host
+		remoteSession, ok := commonProtocol.manager.FindRemoteSession(gid.Value)
+		if !ok {
+			return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+		}
+
+		return commonProtocol.getSessionURLsResponse(packet, callID, gid, remoteSession.HostURLs), nil
+	}
+
 	hostPID := session.GameMatchmakeSession.Gathering.HostPID
 	host := endpoint.FindConnectionByPID(hostPID.Value())
 	if host == nil {
@@ -34,9 +40,15 @@ func (commonProtocol *CommonProtocol) getSessionURLs(err error, packet nex.Packe
 		}
 	}
 
+	return commonProtocol.getSessionURLsResponse(packet, callID, gid, host.StationURLs), nil
+}
+
+func (commonProtocol *CommonProtocol) getSessionURLsResponse(packet nex.PacketInterface, callID uint32, gid *types.PrimitiveU32, stationURLs *types.List[*types.StationURL]) *nex.RMCMessage {
+	endpoint := packet.Sender().Endpoint().(*nex.PRUDPEndPoint)
+
 	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
 
-	host.StationURLs.WriteTo(rmcResponseStream)
+	stationURLs.WriteTo(rmcResponseStream)
 
 	rmcResponseBody := rmcResponseStream.Bytes()
 
@@ -49,5 +61,5 @@ func (commonProtocol *CommonProtocol) getSessionURLs(err error, packet nex.Packe
 		go commonProtocol.OnAfterGetSessionURLs(packet, gid)
 	}
 
-	return rmcResponse, nil
+	return rmcResponse
 }
@@ -9,36 +9,63 @@ import (
 )
 
 type CommonProtocol struct {
-	endpoint                   *nex.PRUDPEndPoint
-	protocol                   match_making.Interface
-	OnAfterUnregisterGathering func(packet nex.PacketInterface, idGathering *types.PrimitiveU32)
-	OnAfterFindBySingleID      func(packet nex.PacketInterface, id *types.PrimitiveU32)
-	OnAfterUpdateSessionURL    func(packet nex.PacketInterface, idGathering *types.PrimitiveU32, strURL *types.String)
-	OnAfterUpdateSessionHostV1 func(packet nex.PacketInterface, gid *types.PrimitiveU32)
-	OnAfterGetSessionURLs      func(packet nex.PacketInterface, gid *types.PrimitiveU32)
-	OnAfterUpdateSessionHost   func(packet nex.PacketInterface, gid *types.PrimitiveU32, isMigrateOwner *types.PrimitiveBool)
+	endpoint                       *nex.PRUDPEndPoint
+	protocol                       match_making.Interface
+	manager                        *common_globals.MatchmakingManager
+	GetUserName                    func(pid *types.PID) string
+	OnAfterUnregisterGathering     func(packet nex.PacketInterface, idGathering *types.PrimitiveU32)
+	OnAfterFindBySingleID          func(packet nex.PacketInterface, id *types.PrimitiveU32)
+	OnAfterUpdateSessionURL        func(packet nex.PacketInterface, idGathering *types.PrimitiveU32, strURL *types.String)
+	OnAfterUpdateSessionHostV1     func(packet nex.PacketInterface, gid *types.PrimitiveU32)
+	OnAfterGetSessionURLs          func(packet nex.PacketInterface, gid *types.PrimitiveU32)
+	OnAfterUpdateSessionHost       func(packet nex.PacketInterface, gid *types.PrimitiveU32, isMigrateOwner *types.PrimitiveBool)
+	OnAfterInvite                  func(packet nex.PacketInterface, idGathering *types.PrimitiveU32, lstPrincipals *types.List[*types.PID], strMessage *types.String)
+	OnAfterAcceptInvitation        func(packet nex.PacketInterface, idGathering *types.PrimitiveU32, strMessage *types.String)
+	OnAfterDeclineInvitation       func(packet nex.PacketInterface, idGathering *types.PrimitiveU32, strMessage *types.String)
+	OnAfterCancelInvitation        func(packet nex.PacketInterface, idGathering *types.PrimitiveU32, lstPrincipals *types.List[*types.PID], strMessage *types.String)
+	OnAfterGetInvitationsSent      func(packet nex.PacketInterface, idGathering *types.PrimitiveU32)
+	OnAfterGetInvitationsReceived  func(packet nex.PacketInterface)
+	OnAfterGetParticipants         func(packet nex.PacketInterface, idGathering *types.PrimitiveU32)
+	OnAfterGetDetailedParticipants func(packet nex.PacketInterface, idGathering *types.PrimitiveU32)
+	OnAfterGetParticipantsURLs     func(packet nex.PacketInterface, idGathering *types.PrimitiveU32)
+	OnAfterFindByOwner             func(packet nex.PacketInterface, id *types.PID, resultRange *types.ResultRange)
 }
 
 // NewCommonProtocol returns a new CommonProtocol
-func NewCommonProtocol(protocol match_making.Interface) *CommonProtocol {
+func NewCommonProtocol(protocol match_making.Interface, manager *common_globals.MatchmakingManager) *CommonProtocol {
 	endpoint := protocol.Endpoint().(*nex.PRUDPEndPoint)
 
 	commonProtocol := &CommonProtocol{
 		endpoint: endpoint,
 		protocol: protocol,
+		manager:  manager,
 	}
 
-	common_globals.MakeSessions()
-
-	protocol.SetHandlerUnregisterGathering(commonProtocol.unregisterGathering)
-	protocol.SetHandlerFindBySingleID(commonProtocol.findBySingleID)
-	protocol.SetHandlerUpdateSessionURL(commonProtocol.updateSessionURL)
-	protocol.SetHandlerUpdateSessionHostV1(commonProtocol.updateSessionHostV1)
-	protocol.SetHandlerGetSessionURLs(commonProtocol.getSessionURLs)
-	protocol.SetHandlerUpdateSessionHost(commonProtocol.updateSessionHost)
+	protocol.SetHandlerUnregisterGathering(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.unregisterGathering))
+	protocol.SetHandlerFindBySingleID(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.findBySingleID))
+	protocol.SetHandlerUpdateSessionURL(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.updateSessionURL))
+	protocol.SetHandlerUpdateSessionHostV1(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.updateSessionHostV1))
+	protocol.SetHandlerGetSessionURLs(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.getSessionURLs))
+	protocol.SetHandlerUpdateSessionHost(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.updateSessionHost))
+	protocol.SetHandlerInvite(common_globals.MeasureHandler3(manager.Metrics(), commonProtocol.invite))
+	protocol.SetHandlerAcceptInvitation(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.acceptInvitation))
+	protocol.SetHandlerDeclineInvitation(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.declineInvitation))
+	protocol.SetHandlerCancelInvitation(common_globals.MeasureHandler3(manager.Metrics(), commonProtocol.cancelInvitation))
+	protocol.SetHandlerGetInvitationsSent(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.getInvitationsSent))
+	protocol.SetHandlerGetInvitationsReceived(common_globals.MeasureHandler0(manager.Metrics(), commonProtocol.getInvitationsReceived))
+	protocol.SetHandlerGetParticipants(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.getParticipants))
+	protocol.SetHandlerGetDetailedParticipants(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.getDetailedParticipants))
+	protocol.SetHandlerGetParticipantsURLs(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.getParticipantsURLs))
+	protocol.SetHandlerFindByOwner(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.findByOwner))
 
 	endpoint.OnConnectionEnded(func(connection *nex.PRUDPConnection) {
-		common_globals.RemoveConnectionFromAllSessions(connection)
+		manager.RemoveConnectionFromAllSessions(connection)
+		manager.ClearConnectivity(connection.ID)
+	})
+
+	// * Add reconnecting players back to the sessions restored from a snapshot
+	endpoint.OnData(func(packet nex.PacketInterface) {
+		manager.RestoreConnection(packet.Sender().(*nex.PRUDPConnection))
 	})
 
 	return commonProtocol
@@ -13,7 +13,7 @@ func (commonProtocol *CommonProtocol) unregisterGathering(err error, packet nex.
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(idGathering.Value)
+	session, ok := commonProtocol.manager.GetSession(idGathering.Value)
 	if !ok {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
@@ -25,7 +25,7 @@ func (commonProtocol *CommonProtocol) unregisterGathering(err error, packet nex.
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
 	}
 
-	common_globals.RemoveSession(connection, idGathering.Value)
+	commonProtocol.manager.RemoveSession(connection, idGathering.Value)
 
 	retval := types.NewPrimitiveBool(true)
 
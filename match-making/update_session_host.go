@@ -2,12 +2,10 @@ package matchmaking
 
 import (
 	"github.com/PretendoNetwork/nex-go/v2"
-	"github.com/PretendoNetwork/nex-go/v2/constants"
 	"github.com/PretendoNetwork/nex-go/v2/types"
 	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
 	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
-	notifications_types "github.com/PretendoNetwork/nex-protocols-go/v2/notifications/types"
 )
 
 func (commonProtocol *CommonProtocol) updateSessionHost(err error, packet nex.PacketInterface, callID uint32, gid *types.PrimitiveU32, isMigrateOwner *types.PrimitiveBool) (*nex.RMCMessage, *nex.Error) {
@@ -16,19 +14,23 @@ func (commonProtocol *CommonProtocol) updateSessionHost(err error, packet nex.Pa
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(gid.Value)
+	session, ok := commonProtocol.manager.GetSession(gid.Value)
 	if !ok {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
 
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
-	server := endpoint.Server
 
-	if common_globals.FindConnectionSession(connection.ID) != gid.Value {
+	if commonProtocol.manager.FindConnectionSession(connection.ID) != gid.Value {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
 	}
 
+	errCode := commonProtocol.manager.VerifyHostUpdate(connection, session)
+	if errCode != nil {
+		return nil, errCode
+	}
+
 	originalHost := session.GameMatchmakeSession.Gathering.HostPID
 	session.GameMatchmakeSession.Gathering.HostPID = connection.PID().Copy().(*types.PID)
 
@@ -37,7 +39,7 @@ func (commonProtocol *CommonProtocol) updateSessionHost(err error, packet nex.Pa
 	rmcResponse.MethodID = match_making.MethodUpdateSessionHost
 	rmcResponse.CallID = callID
 
-	if common_globals.SessionManagementDebugLog {
+	if commonProtocol.manager.SessionManagementDebugLog {
 		common_globals.Logger.Infof("GID %d: UpdateSessionHost from PID %d to PID %d", gid.Value, originalHost.Value(), connection.PID().Value())
 	}
 
@@ -55,57 +57,25 @@ func (commonProtocol *CommonProtocol) updateSessionHost(err error, packet nex.Pa
 	category := notifications.NotificationCategories.OwnershipChanged
 	subtype := notifications.NotificationSubTypes.OwnershipChanged.None
 
-	oEvent := notifications_types.NewNotificationEvent()
+	oEvent := common_globals.NewNotificationEvent()
 	oEvent.PIDSource = originalOwner.Copy().(*types.PID)
 	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
-	oEvent.Param1 = gid.Copy().(*types.PrimitiveU32)
-	oEvent.Param2 = types.NewPrimitiveU32(connection.PID().LegacyValue()) // TODO - This assumes a legacy client. Will not work on the Switch
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(gid.Value))
+	oEvent.Param2 = types.NewPrimitiveU64(connection.PID().Value())
 
 	// TODO - StrParam doesn't have this value on some servers
 	// * https://github.com/kinnay/NintendoClients/issues/101
 	// * unixTime := time.Now()
 	// * oEvent.StrParam = strconv.FormatInt(unixTime.UnixMicro(), 10)
 
-	stream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
-
-	oEvent.WriteTo(stream)
-
-	rmcRequest := nex.NewRMCRequest(endpoint)
-	rmcRequest.ProtocolID = notifications.ProtocolID
-	rmcRequest.CallID = common_globals.CurrentMatchmakingCallID.Next()
-	rmcRequest.MethodID = notifications.MethodProcessNotificationEvent
-	rmcRequest.Parameters = stream.Bytes()
-
-	rmcRequestBytes := rmcRequest.Bytes()
-
-	session.ConnectionIDs.Each(func(_ int, connectionID uint32) bool {
-		target := endpoint.FindConnectionByID(connectionID)
-		if target == nil {
-			common_globals.Logger.Warning("Client not found")
-			return false
-		}
-
-		var messagePacket nex.PRUDPPacketInterface
-
-		if target.DefaultPRUDPVersion == 0 {
-			messagePacket, _ = nex.NewPRUDPPacketV0(server, target, nil)
-		} else {
-			messagePacket, _ = nex.NewPRUDPPacketV1(server, target, nil)
-		}
-
-		messagePacket.SetType(constants.DataPacket)
-		messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-		messagePacket.AddFlag(constants.PacketFlagReliable)
-		messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
-		messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-		messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
-		messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
-		messagePacket.SetPayload(rmcRequestBytes)
-
-		server.Send(messagePacket)
+	failures, errCode := commonProtocol.manager.SendNotificationEventToGathering(gid.Value, oEvent)
+	if errCode != nil {
+		common_globals.Logger.Error(errCode.Error())
+	}
 
-		return false
-	})
+	for _, failure := range failures {
+		common_globals.Logger.Warning(failure.Error())
+	}
 
 	if commonProtocol.OnAfterUpdateSessionHost != nil {
 		go commonProtocol.OnAfterUpdateSessionHost(packet, gid, isMigrateOwner)
@@ -13,7 +13,7 @@ func (commonProtocol *CommonProtocol) updateSessionHostV1(err error, packet nex.
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(gid.Value)
+	session, ok := commonProtocol.manager.GetSession(gid.Value)
 	if !ok {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
@@ -21,17 +21,22 @@ func (commonProtocol *CommonProtocol) updateSessionHostV1(err error, packet nex.
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
 
-	if common_globals.FindConnectionSession(connection.ID) != gid.Value {
+	if commonProtocol.manager.FindConnectionSession(connection.ID) != gid.Value {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
 	}
 
+	errCode := commonProtocol.manager.VerifyHostUpdate(connection, session)
+	if errCode != nil {
+		return nil, errCode
+	}
+
 	originalHost := session.GameMatchmakeSession.Gathering.HostPID
 	session.GameMatchmakeSession.Gathering.HostPID = connection.PID()
 	if session.GameMatchmakeSession.Gathering.Flags.PAND(match_making.GatheringFlags.DisconnectChangeOwner) != 0 {
 		session.GameMatchmakeSession.Gathering.OwnerPID = connection.PID()
 	}
 
-	if common_globals.SessionManagementDebugLog {
+	if commonProtocol.manager.SessionManagementDebugLog {
 		common_globals.Logger.Infof("GID %d: UpdateSessionHost from PID %d to PID %d", gid.Value, originalHost.Value(), connection.PID().Value())
 	}
 
@@ -2,12 +2,10 @@ package matchmaking
 
 import (
 	"github.com/PretendoNetwork/nex-go/v2"
-	"github.com/PretendoNetwork/nex-go/v2/constants"
 	"github.com/PretendoNetwork/nex-go/v2/types"
 	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	match_making "github.com/PretendoNetwork/nex-protocols-go/v2/match-making"
 	notifications "github.com/PretendoNetwork/nex-protocols-go/v2/notifications"
-	notifications_types "github.com/PretendoNetwork/nex-protocols-go/v2/notifications/types"
 )
 
 func (commonProtocol *CommonProtocol) updateSessionURL(err error, packet nex.PacketInterface, callID uint32, idGathering *types.PrimitiveU32, strURL *types.String) (*nex.RMCMessage, *nex.Error) {
@@ -16,20 +14,24 @@ func (commonProtocol *CommonProtocol) updateSessionURL(err error, packet nex.Pac
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(idGathering.Value)
+	session, ok := commonProtocol.manager.GetSession(idGathering.Value)
 	if !ok {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
 
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
-	server := endpoint.Server
+
+	errCode := commonProtocol.manager.VerifyHostUpdate(connection, session)
+	if errCode != nil {
+		return nil, errCode
+	}
 
 	// * Mario Kart 7 seems to set an empty strURL, so I assume this is what the method does?
 	originalHost := session.GameMatchmakeSession.Gathering.HostPID
 	session.GameMatchmakeSession.Gathering.HostPID = connection.PID().Copy().(*types.PID)
 
-	if common_globals.SessionManagementDebugLog {
+	if commonProtocol.manager.SessionManagementDebugLog {
 		common_globals.Logger.Infof("GID %d: UpdateSessionURL HOST from PID %d to PID %d", idGathering.Value, originalHost.Value(), connection.PID().Value())
 	}
 
@@ -49,54 +51,23 @@ func (commonProtocol *CommonProtocol) updateSessionURL(err error, packet nex.Pac
 	category := notifications.NotificationCategories.HostChanged
 	subtype := notifications.NotificationSubTypes.HostChanged.None
 
-	oEvent := notifications_types.NewNotificationEvent()
+	oEvent := common_globals.NewNotificationEvent()
 	oEvent.PIDSource = connection.PID()
 	oEvent.Type = types.NewPrimitiveU32(notifications.BuildNotificationType(category, subtype))
-	oEvent.Param1 = types.NewPrimitiveU32(session.GameMatchmakeSession.Gathering.ID.Value)
-	oEvent.Param2 = types.NewPrimitiveU32(0) // TODO - Research what this means
+	oEvent.Param1 = types.NewPrimitiveU64(uint64(session.GameMatchmakeSession.Gathering.ID.Value))
+	oEvent.Param2 = types.NewPrimitiveU64(0) // TODO - Research what this means
 
 	// TODO - StrParam doesn't have this value on some servers
 	// * https://github.com/kinnay/NintendoClients/issues/101
 	// * unixTime := time.Now()
 	// * oEvent.StrParam = strconv.FormatInt(unixTime.UnixMicro(), 10)
 
-	stream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
-
-	oEvent.WriteTo(stream)
-
-	rmcRequest := nex.NewRMCRequest(endpoint)
-	rmcRequest.ProtocolID = notifications.ProtocolID
-	rmcRequest.CallID = common_globals.CurrentMatchmakingCallID.Next()
-	rmcRequest.MethodID = notifications.MethodProcessNotificationEvent
-	rmcRequest.Parameters = stream.Bytes()
-
-	rmcRequestBytes := rmcRequest.Bytes()
-
-	target := endpoint.FindConnectionByPID(originalHost.Value())
-	if target == nil {
-		common_globals.Logger.Warning("Connection not found")
+	err = commonProtocol.manager.SendNotificationEventToPID(originalHost, oEvent)
+	if err != nil {
+		common_globals.Logger.Warning(err.Error())
 		return rmcResponse, nil
 	}
 
-	var messagePacket nex.PRUDPPacketInterface
-
-	if target.DefaultPRUDPVersion == 0 {
-		messagePacket, _ = nex.NewPRUDPPacketV0(server, target, nil)
-	} else {
-		messagePacket, _ = nex.NewPRUDPPacketV1(server, target, nil)
-	}
-
-	messagePacket.SetType(constants.DataPacket)
-	messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-	messagePacket.AddFlag(constants.PacketFlagReliable)
-	messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
-	messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-	messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
-	messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
-	messagePacket.SetPayload(rmcRequestBytes)
-
-	server.Send(messagePacket)
-
 	if commonProtocol.OnAfterUpdateSessionURL != nil {
 		go commonProtocol.OnAfterUpdateSessionURL(packet, idGathering, strURL)
 	}
@@ -24,7 +24,7 @@ func (commonProtocol *CommonProtocol) autoMatchmakePostpone(err error, packet ne
 
 	// * A client may disconnect from a session without leaving reliably,
 	// * so let's make sure the client is removed from the session
-	common_globals.RemoveConnectionFromAllSessions(connection)
+	commonProtocol.manager.RemoveConnectionFromAllSessions(connection)
 
 	var matchmakeSession *match_making_types.MatchmakeSession
 	anyGatheringDataType := anyGathering.TypeName
@@ -39,29 +39,71 @@ func (commonProtocol *CommonProtocol) autoMatchmakePostpone(err error, packet ne
 	searchMatchmakeSession := matchmakeSession.Copy().(*match_making_types.MatchmakeSession)
 	dirtySearchMatchmakeSession := matchmakeSession.Copy().(*match_making_types.MatchmakeSession)
 	commonProtocol.CleanupSearchMatchmakeSession(searchMatchmakeSession)
-	sessionIndex := common_globals.FindSessionByMatchmakeSession(connection, searchMatchmakeSession, dirtySearchMatchmakeSession)
+
+	// * AutoMatchmake_Postpone has no join options, the client always joins alone
+	participationCount := uint16(1)
+
+	sessionIndex := commonProtocol.manager.FindSessionByMatchmakeSession(connection, searchMatchmakeSession, dirtySearchMatchmakeSession, participationCount)
 	var session *common_globals.CommonMatchmakeSession
 
+	// * Sessions of other instances are only joined when there is no local one
 	if sessionIndex == 0 {
-		var errCode *nex.Error
-		session, errCode = common_globals.CreateSessionByMatchmakeSession(matchmakeSession, searchMatchmakeSession, connection.PID())
-		if err != nil {
-			common_globals.Logger.Error(errCode.Error())
-			return nil, errCode
+		remoteSession := commonProtocol.manager.FindRemoteSessionByMatchmakeSession(connection, searchMatchmakeSession, participationCount)
+		if remoteSession != nil {
+			// * The client also cares about its block list
+			joinOptions := common_globals.SessionJoinOptions{
+				DontCareMyBlockList: false,
+				ParticipationCount:  participationCount,
+			}
+
+			joinedMatchmakeSession, errCode := commonProtocol.manager.JoinRemoteSession(connection, remoteSession.GatheringID, message.Value, joinOptions)
+			if errCode != nil {
+				// * The session may have filled up or closed since it was published, so look for another one below
+				common_globals.Logger.Warning(errCode.Error())
+			} else {
+				// * Only used to build the response
+				session = &common_globals.CommonMatchmakeSession{GameMatchmakeSession: joinedMatchmakeSession}
+			}
 		}
-	} else {
-		var ok bool
-		session, ok = common_globals.GetSession(sessionIndex)
-		// TOCTOU, just in case
-		if !ok {
-			return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+	}
+
+	// * In queue mode, wait for other searchers instead of creating a session right away.
+	// * Searches which would fail to create a session are left to the usual path below
+	if sessionIndex == 0 && session == nil && commonProtocol.manager.MatchmakingQueue != nil && commonProtocol.verifyCommunityMatchmakeSession(matchmakeSession) == nil {
+		session = commonProtocol.manager.QueueAutoMatchmake(connection, matchmakeSession, searchMatchmakeSession, dirtySearchMatchmakeSession, message.Value)
+		if session == nil {
+			// * The session of the batch may have filled up, and other sessions may have been created while waiting
+			sessionIndex = commonProtocol.manager.FindSessionByMatchmakeSession(connection, searchMatchmakeSession, dirtySearchMatchmakeSession, participationCount)
 		}
 	}
 
-	errCode := common_globals.AddPlayersToSession(session, []uint32{connection.ID}, connection, message.Value)
-	if errCode != nil {
-		common_globals.Logger.Error(errCode.Error())
-		return nil, errCode
+	if session == nil {
+		if sessionIndex == 0 {
+			var errCode *nex.Error
+			errCode = commonProtocol.verifyCommunityMatchmakeSession(matchmakeSession)
+			if errCode != nil {
+				return nil, errCode
+			}
+
+			session, errCode = commonProtocol.manager.CreateSessionByMatchmakeSession(matchmakeSession, searchMatchmakeSession, connection.PID())
+			if errCode != nil {
+				common_globals.Logger.Error(errCode.Error())
+				return nil, errCode
+			}
+		} else {
+			var ok bool
+			session, ok = commonProtocol.manager.GetSession(sessionIndex)
+			// TOCTOU, just in case
+			if !ok {
+				return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
+			}
+		}
+
+		errCode := commonProtocol.manager.AddPlayersToSession(session, []uint32{connection.ID}, connection, message.Value)
+		if errCode != nil {
+			common_globals.Logger.Error(errCode.Error())
+			return nil, errCode
+		}
 	}
 
 	matchmakeDataHolder := types.NewAnyDataHolder()
@@ -19,25 +19,27 @@ func (commonProtocol *CommonProtocol) autoMatchmakeWithParamPostpone(err error,
 
 	// * A client may disconnect from a session without leaving reliably,
 	// * so let's make sure the client is removed from the session
-	common_globals.RemoveConnectionFromAllSessions(connection)
+	commonProtocol.manager.RemoveConnectionFromAllSessions(connection)
 
 	matchmakeSession := autoMatchmakeParam.SourceMatchmakeSession
 
-	sessions := common_globals.FindSessionsByMatchmakeSessionSearchCriterias(connection, autoMatchmakeParam.LstSearchCriteria.Slice(), commonProtocol.GameSpecificMatchmakeSessionSearchCriteriaChecks)
-	var session *common_globals.CommonMatchmakeSession
+	session := commonProtocol.manager.FindSessionToJoin(connection, autoMatchmakeParam.LstSearchCriteria.Slice(), commonProtocol.GameSpecificMatchmakeSessionSearchCriteriaChecks, autoMatchmakeParam.ParticipationCount.Value)
 
-	if len(sessions) == 0 {
+	if session == nil {
 		var errCode *nex.Error
-		session, errCode = common_globals.CreateSessionByMatchmakeSession(matchmakeSession, nil, connection.PID())
+		errCode = commonProtocol.verifyCommunityMatchmakeSession(matchmakeSession)
+		if errCode != nil {
+			return nil, errCode
+		}
+
+		session, errCode = commonProtocol.manager.CreateSessionByMatchmakeSession(matchmakeSession, nil, connection.PID())
 		if errCode != nil {
 			common_globals.Logger.Error(errCode.Error())
 			return nil, errCode
 		}
-	} else {
-		session = sessions[0]
 	}
 
-	errCode := common_globals.AddPlayersToSession(session, []uint32{connection.ID}, connection, "")
+	errCode := commonProtocol.manager.AddPartyToSession(session, connection, autoMatchmakeParam.ParticipationCount.Value, "")
 	if errCode != nil {
 		common_globals.Logger.Error(errCode.Error())
 		return nil, errCode
@@ -19,7 +19,7 @@ func (commonProtocol *CommonProtocol) autoMatchmakeWithSearchCriteriaPostpone(er
 
 	// * A client may disconnect from a session without leaving reliably,
 	// * so let's make sure the client is removed from the session
-	common_globals.RemoveConnectionFromAllSessions(connection)
+	commonProtocol.manager.RemoveConnectionFromAllSessions(connection)
 
 	var matchmakeSession *match_making_types.MatchmakeSession
 
@@ -30,21 +30,23 @@ func (commonProtocol *CommonProtocol) autoMatchmakeWithSearchCriteriaPostpone(er
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	sessions := common_globals.FindSessionsByMatchmakeSessionSearchCriterias(connection, lstSearchCriteria.Slice(), commonProtocol.GameSpecificMatchmakeSessionSearchCriteriaChecks)
-	var session *common_globals.CommonMatchmakeSession
+	session := commonProtocol.manager.FindSessionToJoin(connection, lstSearchCriteria.Slice(), commonProtocol.GameSpecificMatchmakeSessionSearchCriteriaChecks, 1)
 
-	if len(sessions) == 0 {
+	if session == nil {
 		var errCode *nex.Error
-		session, errCode = common_globals.CreateSessionByMatchmakeSession(matchmakeSession, nil, connection.PID())
+		errCode = commonProtocol.verifyCommunityMatchmakeSession(matchmakeSession)
+		if errCode != nil {
+			return nil, errCode
+		}
+
+		session, errCode = commonProtocol.manager.CreateSessionByMatchmakeSession(matchmakeSession, nil, connection.PID())
 		if errCode != nil {
 			common_globals.Logger.Error(errCode.Error())
 			return nil, errCode
 		}
-	} else {
-		session = sessions[0]
 	}
 
-	errCode := common_globals.AddPlayersToSession(session, []uint32{connection.ID}, connection, strMessage.Value)
+	errCode := commonProtocol.manager.AddPlayersToSession(session, []uint32{connection.ID}, connection, strMessage.Value)
 	if errCode != nil {
 		common_globals.Logger.Error(errCode.Error())
 		return nil, errCode
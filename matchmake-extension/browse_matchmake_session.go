@@ -1,8 +1,6 @@
 package matchmake_extension
 
 import (
-	"math"
-
 	"github.com/PretendoNetwork/nex-go/v2"
 	"github.com/PretendoNetwork/nex-go/v2/types"
 	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
@@ -21,20 +19,10 @@ func (commonProtocol *CommonProtocol) browseMatchmakeSession(err error, packet n
 
 	searchCriterias := []*match_making_types.MatchmakeSessionSearchCriteria{searchCriteria}
 
-	sessions := common_globals.FindSessionsByMatchmakeSessionSearchCriterias(connection, searchCriterias, commonProtocol.GameSpecificMatchmakeSessionSearchCriteriaChecks)
-
-	// TODO - Is this right?
-	if resultRange.Offset.Value != math.MaxUint32 {
-		if len(sessions) < int(resultRange.Offset.Value) {
-			return nil, nex.NewError(nex.ResultCodes.Core.InvalidIndex, "change_error")
-		}
-
-		sessions = sessions[resultRange.Offset.Value:]
-	}
-
-
-	if len(sessions) > int(resultRange.Length.Value) {
-		sessions = sessions[:resultRange.Length.Value]
+	// TODO - Is treating math.MaxUint32 as offset 0 right?
+	sessions, errCode := commonProtocol.manager.BrowseSessions(connection, searchCriterias, commonProtocol.GameSpecificMatchmakeSessionSearchCriteriaChecks, resultRange.Offset.Value, resultRange.Length.Value)
+	if errCode != nil {
+		return nil, errCode
 	}
 
 	lstGathering := types.NewList[*types.AnyDataHolder]()
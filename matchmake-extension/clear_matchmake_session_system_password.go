@@ -0,0 +1,34 @@
+package matchmake_extension
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	matchmake_extension "github.com/PretendoNetwork/nex-protocols-go/v2/matchmake-extension"
+)
+
+func (commonProtocol *CommonProtocol) clearMatchmakeSessionSystemPassword(err error, packet nex.PacketInterface, callID uint32, gid *types.PrimitiveU32) (*nex.RMCMessage, *nex.Error) {
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	errCode := commonProtocol.manager.ClearSessionSystemPassword(connection, gid.Value)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
+	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
+	rmcResponse.MethodID = matchmake_extension.MethodClearMatchmakeSessionSystemPassword
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterClearMatchmakeSessionSystemPassword != nil {
+		go commonProtocol.OnAfterClearMatchmakeSessionSystemPassword(packet, gid)
+	}
+
+	return rmcResponse, nil
+}
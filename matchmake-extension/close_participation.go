@@ -13,7 +13,7 @@ func (commonProtocol *CommonProtocol) closeParticipation(err error, packet nex.P
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(gid.Value)
+	session, ok := commonProtocol.manager.GetSession(gid.Value)
 	if !ok {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
@@ -25,7 +25,10 @@ func (commonProtocol *CommonProtocol) closeParticipation(err error, packet nex.P
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
 	}
 
-	session.GameMatchmakeSession.OpenParticipation = types.NewPrimitiveBool(false)
+	errCode := commonProtocol.manager.SetSessionOpenParticipation(gid.Value, false)
+	if errCode != nil {
+		return nil, errCode
+	}
 
 	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
 	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
@@ -0,0 +1,80 @@
+package matchmake_extension
+
+import (
+	"time"
+
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+)
+
+// communityOfMatchmakeSession returns the gathering ID of the community a MatchmakeSession belongs to.
+// Returns false if the MatchmakeSession doesn't belong to any community
+func (commonProtocol *CommonProtocol) communityOfMatchmakeSession(matchmakeSession *match_making_types.MatchmakeSession) (uint32, bool) {
+	if commonProtocol.IsCommunityMatchmakeSession == nil || !commonProtocol.IsCommunityMatchmakeSession(matchmakeSession) {
+		return 0, false
+	}
+
+	attribute, err := matchmakeSession.Attributes.Get(commonProtocol.CommunityAttributeIndex)
+	if err != nil {
+		return 0, false
+	}
+
+	return attribute.Value, true
+}
+
+// communitySessionCount returns the number of sessions which belong to the community
+func (commonProtocol *CommonProtocol) communitySessionCount(gatheringID uint32) uint32 {
+	var count uint32
+	commonProtocol.manager.EachSession(func(_ uint32, session *common_globals.CommonMatchmakeSession) bool {
+		communityGatheringID, ok := commonProtocol.communityOfMatchmakeSession(session.GameMatchmakeSession)
+		if ok && communityGatheringID == gatheringID {
+			count++
+		}
+
+		return false
+	})
+
+	return count
+}
+
+// communityResponse prepares a community to be sent to a client, leaving out its password
+func (commonProtocol *CommonProtocol) communityResponse(community *match_making_types.PersistentGathering) *match_making_types.PersistentGathering {
+	result := community.Copy().(*match_making_types.PersistentGathering)
+	result.Password = types.NewString("")
+	result.MatchmakeSessionCount = types.NewPrimitiveU32(commonProtocol.communitySessionCount(community.ID.Value))
+
+	return result
+}
+
+// verifyCommunityMatchmakeSession checks if a MatchmakeSession can be created or joined in the community it references, if any.
+// Returns a NEX error code if it can't
+func (commonProtocol *CommonProtocol) verifyCommunityMatchmakeSession(matchmakeSession *match_making_types.MatchmakeSession) *nex.Error {
+	gatheringID, ok := commonProtocol.communityOfMatchmakeSession(matchmakeSession)
+	if !ok {
+		return nil
+	}
+
+	if commonProtocol.GetCommunityByGatheringID == nil {
+		common_globals.Logger.Warning("GetCommunityByGatheringID not defined")
+		return nex.NewError(nex.ResultCodes.Core.NotImplemented, "change_error")
+	}
+
+	community, errCode := commonProtocol.GetCommunityByGatheringID(gatheringID)
+	if errCode != nil {
+		return errCode
+	}
+
+	// * A zero date means there is no limit
+	now := time.Now()
+	if community.ParticipationStartDate.Value() != 0 && now.Before(community.ParticipationStartDate.Standard()) {
+		return nex.NewError(nex.ResultCodes.RendezVous.SessionClosed, "change_error")
+	}
+
+	if community.ParticipationEndDate.Value() != 0 && now.After(community.ParticipationEndDate.Standard()) {
+		return nex.NewError(nex.ResultCodes.RendezVous.SessionClosed, "change_error")
+	}
+
+	return nil
+}
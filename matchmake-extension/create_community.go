@@ -0,0 +1,53 @@
+package matchmake_extension
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	matchmake_extension "github.com/PretendoNetwork/nex-protocols-go/v2/matchmake-extension"
+)
+
+func (commonProtocol *CommonProtocol) createCommunity(err error, packet nex.PacketInterface, callID uint32, community *match_making_types.PersistentGathering, strMessage *types.String) (*nex.RMCMessage, *nex.Error) {
+	if commonProtocol.InitializeCommunity == nil {
+		common_globals.Logger.Warning("InitializeCommunity not defined")
+		return nil, nex.NewError(nex.ResultCodes.Core.NotImplemented, "change_error")
+	}
+
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	community.Gathering.OwnerPID = connection.PID()
+	community.Gathering.HostPID = connection.PID()
+
+	// * Communities share the gathering IDs of the sessions, so the community sessions can reference them unambiguously
+	community.Gathering.ID = types.NewPrimitiveU32(commonProtocol.manager.AllocateGatheringID())
+
+	errCode := commonProtocol.InitializeCommunity(community, strMessage.Value)
+	if errCode != nil {
+		common_globals.Logger.Error(errCode.Error())
+		return nil, errCode
+	}
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	community.Gathering.ID.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
+	rmcResponse.MethodID = matchmake_extension.MethodCreateCommunity
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterCreateCommunity != nil {
+		go commonProtocol.OnAfterCreateCommunity(packet, community, strMessage)
+	}
+
+	return rmcResponse, nil
+}
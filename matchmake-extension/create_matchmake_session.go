@@ -20,7 +20,7 @@ func (commonProtocol *CommonProtocol) createMatchmakeSession(err error, packet n
 
 	// * A client may disconnect from a session without leaving reliably,
 	// * so let's make sure the client is removed from the session
-	common_globals.RemoveConnectionFromAllSessions(connection)
+	commonProtocol.manager.RemoveConnectionFromAllSessions(connection)
 
 	var matchmakeSession *match_making_types.MatchmakeSession
 
@@ -31,13 +31,18 @@ func (commonProtocol *CommonProtocol) createMatchmakeSession(err error, packet n
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, errCode := common_globals.CreateSessionByMatchmakeSession(matchmakeSession, nil, connection.PID())
+	errCode := commonProtocol.verifyCommunityMatchmakeSession(matchmakeSession)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	session, errCode := commonProtocol.manager.CreateSessionByMatchmakeSession(matchmakeSession, nil, connection.PID())
 	if errCode != nil {
 		common_globals.Logger.Error(errCode.Error())
 		return nil, errCode
 	}
 
-	errCode = common_globals.AddPlayersToSession(session, []uint32{connection.ID}, connection, message.Value)
+	errCode = commonProtocol.manager.AddPartyToSession(session, connection, participationCount.Value, message.Value)
 	if errCode != nil {
 		common_globals.Logger.Error(errCode.Error())
 		return nil, errCode
@@ -18,16 +18,21 @@ func (commonProtocol *CommonProtocol) createMatchmakeSessionWithParam(err error,
 
 	// * A client may disconnect from a session without leaving reliably,
 	// * so let's make sure the client is removed from all sessions
-	common_globals.RemoveConnectionFromAllSessions(connection)
+	commonProtocol.manager.RemoveConnectionFromAllSessions(connection)
 
 	joinedMatchmakeSession := createMatchmakeSessionParam.SourceMatchmakeSession.Copy().(*match_making_types.MatchmakeSession)
-	session, errCode := common_globals.CreateSessionByMatchmakeSession(joinedMatchmakeSession, nil, connection.PID())
+	errCode := commonProtocol.verifyCommunityMatchmakeSession(joinedMatchmakeSession)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	session, errCode := commonProtocol.manager.CreateSessionByMatchmakeSession(joinedMatchmakeSession, nil, connection.PID())
 	if errCode != nil {
 		common_globals.Logger.Error(errCode.Error())
 		return nil, errCode
 	}
 
-	errCode = common_globals.AddPlayersToSession(session, []uint32{connection.ID}, connection, createMatchmakeSessionParam.JoinMessage.Value)
+	errCode = commonProtocol.manager.AddPartyToSession(session, connection, createMatchmakeSessionParam.ParticipationCount.Value, createMatchmakeSessionParam.JoinMessage.Value)
 	if errCode != nil {
 		common_globals.Logger.Error(errCode.Error())
 		return nil, errCode
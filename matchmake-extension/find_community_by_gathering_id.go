@@ -0,0 +1,54 @@
+package matchmake_extension
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	matchmake_extension "github.com/PretendoNetwork/nex-protocols-go/v2/matchmake-extension"
+)
+
+func (commonProtocol *CommonProtocol) findCommunityByGatheringID(err error, packet nex.PacketInterface, callID uint32, lstGID *types.List[*types.PrimitiveU32]) (*nex.RMCMessage, *nex.Error) {
+	if commonProtocol.GetCommunityByGatheringID == nil {
+		common_globals.Logger.Warning("GetCommunityByGatheringID not defined")
+		return nil, nex.NewError(nex.ResultCodes.Core.NotImplemented, "change_error")
+	}
+
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	lstCommunity := types.NewList[*match_making_types.PersistentGathering]()
+	lstCommunity.Type = match_making_types.NewPersistentGathering()
+
+	// * Communities which can't be found are left out
+	lstGID.Each(func(_ int, gid *types.PrimitiveU32) bool {
+		community, errCode := commonProtocol.GetCommunityByGatheringID(gid.Value)
+		if errCode == nil {
+			lstCommunity.Append(commonProtocol.communityResponse(community))
+		}
+
+		return false
+	})
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	lstCommunity.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
+	rmcResponse.MethodID = matchmake_extension.MethodFindCommunityByGatheringID
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterFindCommunityByGatheringID != nil {
+		go commonProtocol.OnAfterFindCommunityByGatheringID(packet, lstGID)
+	}
+
+	return rmcResponse, nil
+}
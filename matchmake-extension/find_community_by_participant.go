@@ -0,0 +1,54 @@
+package matchmake_extension
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	matchmake_extension "github.com/PretendoNetwork/nex-protocols-go/v2/matchmake-extension"
+)
+
+func (commonProtocol *CommonProtocol) findCommunityByParticipant(err error, packet nex.PacketInterface, callID uint32, pid *types.PID, resultRange *types.ResultRange) (*nex.RMCMessage, *nex.Error) {
+	if commonProtocol.GetCommunitiesByParticipant == nil {
+		common_globals.Logger.Warning("GetCommunitiesByParticipant not defined")
+		return nil, nex.NewError(nex.ResultCodes.Core.NotImplemented, "change_error")
+	}
+
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	communities, errCode := commonProtocol.GetCommunitiesByParticipant(pid, resultRange)
+	if errCode != nil {
+		common_globals.Logger.Error(errCode.Error())
+		return nil, errCode
+	}
+
+	lstCommunity := types.NewList[*match_making_types.PersistentGathering]()
+	lstCommunity.Type = match_making_types.NewPersistentGathering()
+
+	for _, community := range communities {
+		lstCommunity.Append(commonProtocol.communityResponse(community))
+	}
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	lstCommunity.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
+	rmcResponse.MethodID = matchmake_extension.MethodFindCommunityByParticipant
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterFindCommunityByParticipant != nil {
+		go commonProtocol.OnAfterFindCommunityByParticipant(packet, pid, resultRange)
+	}
+
+	return rmcResponse, nil
+}
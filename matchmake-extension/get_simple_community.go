@@ -0,0 +1,60 @@
+package matchmake_extension
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	matchmake_extension "github.com/PretendoNetwork/nex-protocols-go/v2/matchmake-extension"
+)
+
+func (commonProtocol *CommonProtocol) getSimpleCommunity(err error, packet nex.PacketInterface, callID uint32, gatheringIDList *types.List[*types.PrimitiveU32]) (*nex.RMCMessage, *nex.Error) {
+	if commonProtocol.GetCommunityByGatheringID == nil {
+		common_globals.Logger.Warning("GetCommunityByGatheringID not defined")
+		return nil, nex.NewError(nex.ResultCodes.Core.NotImplemented, "change_error")
+	}
+
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	lstSimpleCommunity := types.NewList[*match_making_types.SimpleCommunity]()
+	lstSimpleCommunity.Type = match_making_types.NewSimpleCommunity()
+
+	// * Communities which can't be found are left out
+	gatheringIDList.Each(func(_ int, gid *types.PrimitiveU32) bool {
+		_, errCode := commonProtocol.GetCommunityByGatheringID(gid.Value)
+		if errCode != nil {
+			return false
+		}
+
+		simpleCommunity := match_making_types.NewSimpleCommunity()
+		simpleCommunity.GatheringID = types.NewPrimitiveU32(gid.Value)
+		simpleCommunity.MatchmakeSessionCount = types.NewPrimitiveU32(commonProtocol.communitySessionCount(gid.Value))
+
+		lstSimpleCommunity.Append(simpleCommunity)
+
+		return false
+	})
+
+	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
+
+	lstSimpleCommunity.WriteTo(rmcResponseStream)
+
+	rmcResponseBody := rmcResponseStream.Bytes()
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, rmcResponseBody)
+	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
+	rmcResponse.MethodID = matchmake_extension.MethodGetSimpleCommunity
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterGetSimpleCommunity != nil {
+		go commonProtocol.OnAfterGetSimpleCommunity(packet, gatheringIDList)
+	}
+
+	return rmcResponse, nil
+}
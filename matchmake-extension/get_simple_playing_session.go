@@ -29,7 +29,7 @@ func (commonProtocol *CommonProtocol) getSimplePlayingSession(err error, packet
 
 	simplePlayingSessions := make(map[string]*match_making_types.SimplePlayingSession)
 	
-	if common_globals.EachSession((func(gatheringID uint32, session *common_globals.CommonMatchmakeSession) bool {
+	if commonProtocol.manager.EachSession((func(gatheringID uint32, session *common_globals.CommonMatchmakeSession) bool {
 		for _, pid := range listPID.Slice() {
 			key := fmt.Sprintf("%d-%d", gatheringID, pid.Value())
 			if simplePlayingSessions[key] == nil {
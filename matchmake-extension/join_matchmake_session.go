@@ -4,6 +4,7 @@ import (
 	"github.com/PretendoNetwork/nex-go/v2"
 	"github.com/PretendoNetwork/nex-go/v2/types"
 	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
 	matchmake_extension "github.com/PretendoNetwork/nex-protocols-go/v2/matchmake-extension"
 )
 
@@ -13,23 +14,39 @@ func (commonProtocol *CommonProtocol) joinMatchmakeSession(err error, packet nex
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(gid.Value)
-	if !ok {
-		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
-	}
-
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
 	server := endpoint.Server
 
-	// TODO - More checks here
-	errCode := common_globals.AddPlayersToSession(session, []uint32{connection.ID}, connection, strMessage.Value)
-	if errCode != nil {
-		common_globals.Logger.Error(errCode.Error())
-		return nil, errCode
+	joinOptions := common_globals.SessionJoinOptions{
+		DontCareMyBlockList: false,
 	}
 
-	joinedMatchmakeSession := session.GameMatchmakeSession
+	var joinedMatchmakeSession *match_making_types.MatchmakeSession
+
+	session, ok := commonProtocol.manager.GetSession(gid.Value)
+	if !ok {
+		// * The session may be held by another instance
+		var errCode *nex.Error
+		joinedMatchmakeSession, errCode = commonProtocol.manager.JoinRemoteSession(connection, gid.Value, strMessage.Value, joinOptions)
+		if errCode != nil {
+			return nil, errCode
+		}
+	} else {
+		// TODO - More checks here
+		errCode := commonProtocol.manager.VerifySessionJoin(connection, session, joinOptions)
+		if errCode != nil {
+			return nil, errCode
+		}
+
+		errCode = commonProtocol.manager.AddPlayersToSession(session, []uint32{connection.ID}, connection, strMessage.Value)
+		if errCode != nil {
+			common_globals.Logger.Error(errCode.Error())
+			return nil, errCode
+		}
+
+		joinedMatchmakeSession = session.GameMatchmakeSession
+	}
 
 	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
 
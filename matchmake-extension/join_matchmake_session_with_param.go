@@ -13,24 +13,45 @@ func (commonProtocol *CommonProtocol) joinMatchmakeSessionWithParam(err error, p
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(joinMatchmakeSessionParam.GID.Value)
-	if !ok {
-		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
-	}
-
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
 
-	// TODO - More checks here
-	errCode := common_globals.AddPlayersToSession(session, []uint32{connection.ID}, connection, joinMatchmakeSessionParam.JoinMessage.Value)
-	if errCode != nil {
-		common_globals.Logger.Error(errCode.Error())
-		return nil, errCode
+	joinOptions := common_globals.SessionJoinOptions{
+		DontCareMyBlockList: false, // TODO - Does BlockListParam.OptionFlag control this?
+		UserPassword:        joinMatchmakeSessionParam.StrUserPassword.Value,
+		SystemPassword:      joinMatchmakeSessionParam.StrSystemPassword.Value,
+		ParticipationCount:  joinMatchmakeSessionParam.ParticipationCount.Value,
+	}
+
+	var joinedMatchmakeSession *match_making_types.MatchmakeSession
+
+	session, ok := commonProtocol.manager.GetSession(joinMatchmakeSessionParam.GID.Value)
+	if !ok {
+		// * The session may be held by another instance
+		var errCode *nex.Error
+		joinedMatchmakeSession, errCode = commonProtocol.manager.JoinRemoteSession(connection, joinMatchmakeSessionParam.GID.Value, joinMatchmakeSessionParam.JoinMessage.Value, joinOptions)
+		if errCode != nil {
+			return nil, errCode
+		}
+	} else {
+		// TODO - More checks here
+		errCode := commonProtocol.manager.VerifySessionJoin(connection, session, joinOptions)
+		if errCode != nil {
+			return nil, errCode
+		}
+
+		errCode = commonProtocol.manager.AddPartyToSession(session, connection, joinOptions.ParticipationCount, joinMatchmakeSessionParam.JoinMessage.Value)
+		if errCode != nil {
+			common_globals.Logger.Error(errCode.Error())
+			return nil, errCode
+		}
+
+		joinedMatchmakeSession = session.GameMatchmakeSession
 	}
 
 	rmcResponseStream := nex.NewByteStreamOut(endpoint.LibraryVersions(), endpoint.ByteStreamSettings())
 
-	session.GameMatchmakeSession.WriteTo(rmcResponseStream)
+	joinedMatchmakeSession.WriteTo(rmcResponseStream)
 
 	rmcResponseBody := rmcResponseStream.Bytes()
 
@@ -16,7 +16,7 @@ func (commonProtocol *CommonProtocol) modifyCurrentGameAttribute(err error, pack
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
 
-	session, ok := common_globals.GetSession(gid.Value)
+	session, ok := commonProtocol.manager.GetSession(gid.Value)
 	if !ok {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
@@ -32,6 +32,7 @@ func (commonProtocol *CommonProtocol) modifyCurrentGameAttribute(err error, pack
 	}
 
 	session.GameMatchmakeSession.Attributes.SetIndex(index, newValue.Copy().(*types.PrimitiveU32))
+	session.MarkActivity()
 
 	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
 	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
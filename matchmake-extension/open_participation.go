@@ -16,7 +16,7 @@ func (commonProtocol *CommonProtocol) openParticipation(err error, packet nex.Pa
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
 
-	session, ok := common_globals.GetSession(gid.Value)
+	session, ok := commonProtocol.manager.GetSession(gid.Value)
 	if !ok {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
@@ -25,7 +25,10 @@ func (commonProtocol *CommonProtocol) openParticipation(err error, packet nex.Pa
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
 	}
 
-	session.GameMatchmakeSession.OpenParticipation = types.NewPrimitiveBool(true)
+	errCode := commonProtocol.manager.SetSessionOpenParticipation(gid.Value, true)
+	if errCode != nil {
+		return nil, errCode
+	}
 
 	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
 	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
@@ -12,8 +12,16 @@ import (
 type CommonProtocol struct {
 	endpoint                                         nex.EndpointInterface
 	protocol                                         matchmake_extension.Interface
+	manager                                          *common_globals.MatchmakingManager
 	CleanupSearchMatchmakeSession                    func(matchmakeSession *match_making_types.MatchmakeSession)
 	GameSpecificMatchmakeSessionSearchCriteriaChecks func(searchCriteria *match_making_types.MatchmakeSessionSearchCriteria, matchmakeSession *match_making_types.MatchmakeSession) bool
+	IsCommunityMatchmakeSession                      func(matchmakeSession *match_making_types.MatchmakeSession) bool                   // * Tells if a MatchmakeSession belongs to a community, such as by its game mode
+	CommunityAttributeIndex                          int                                                                                // * Index of the MatchmakeSession attribute holding the gathering ID of its community
+	InitializeCommunity                              func(community *match_making_types.PersistentGathering, message string) *nex.Error // * The gathering ID of the community is allocated by the MatchmakingManager beforehand
+	GetCommunityByGatheringID                        func(gid uint32) (*match_making_types.PersistentGathering, *nex.Error)
+	GetOfficialCommunities                           func(isAvailableOnly bool, resultRange *types.ResultRange) ([]*match_making_types.PersistentGathering, *nex.Error)
+	GetCommunitiesByParticipant                      func(pid *types.PID, resultRange *types.ResultRange) ([]*match_making_types.PersistentGathering, *nex.Error)
+	UpdateCommunityByGatheringID                     func(community *match_making_types.PersistentGathering) *nex.Error
 	OnAfterOpenParticipation                         func(packet nex.PacketInterface, gid *types.PrimitiveU32)
 	OnAfterCloseParticipation                        func(packet nex.PacketInterface, gid *types.PrimitiveU32)
 	OnAfterCreateMatchmakeSession                    func(packet nex.PacketInterface, anyGathering *types.AnyDataHolder, message *types.String, participationCount *types.PrimitiveU16)
@@ -29,35 +37,74 @@ type CommonProtocol struct {
 	OnAfterModifyCurrentGameAttribute                func(packet nex.PacketInterface, gid *types.PrimitiveU32, attribIndex *types.PrimitiveU32, newValue *types.PrimitiveU32)
 	OnAfterBrowseMatchmakeSession                    func(packet nex.PacketInterface, searchCriteria *match_making_types.MatchmakeSessionSearchCriteria, resultRange *types.ResultRange)
 	OnAfterJoinMatchmakeSessionEx                    func(packet nex.PacketInterface, gid *types.PrimitiveU32, strMessage *types.String, dontCareMyBlockList *types.PrimitiveBool, participationCount *types.PrimitiveU16)
+	OnAfterGenerateMatchmakeSessionSystemPassword    func(packet nex.PacketInterface, gid *types.PrimitiveU32)
+	OnAfterClearMatchmakeSessionSystemPassword       func(packet nex.PacketInterface, gid *types.PrimitiveU32)
+	OnAfterCreateCommunity                           func(packet nex.PacketInterface, community *match_making_types.PersistentGathering, strMessage *types.String)
+	OnAfterUpdateCommunity                           func(packet nex.PacketInterface, community *match_making_types.PersistentGathering)
+	OnAfterFindCommunityByGatheringID                func(packet nex.PacketInterface, lstGID *types.List[*types.PrimitiveU32])
+	OnAfterFindOfficialCommunity                     func(packet nex.PacketInterface, isAvailableOnly *types.PrimitiveBool, resultRange *types.ResultRange)
+	OnAfterFindCommunityByParticipant                func(packet nex.PacketInterface, pid *types.PID, resultRange *types.ResultRange)
+	OnAfterGetSimpleCommunity                        func(packet nex.PacketInterface, gatheringIDList *types.List[*types.PrimitiveU32])
 }
 
-// GetUserFriendPIDs sets the GetUserFriendPIDs handler function
+// GetUserFriendPIDs sets the GetUserFriendPIDs handler function from a legacy handler using 32 bit PIDs.
+// Use GetUserFriendPIDsByPID to support 64 bit PIDs
 func (commonProtocol *CommonProtocol) GetUserFriendPIDs(handler func(pid uint32) []uint32) {
-	common_globals.GetUserFriendPIDsHandler = handler
+	commonProtocol.manager.GetUserFriendPIDs = common_globals.LegacyGetUserFriendPIDs(handler)
+}
+
+// GetUserFriendPIDsByPID sets the GetUserFriendPIDs handler function, which returns the friends of a user
+func (commonProtocol *CommonProtocol) GetUserFriendPIDsByPID(handler func(pid *types.PID) []*types.PID) {
+	commonProtocol.manager.GetUserFriendPIDs = handler
+}
+
+// GetUserBlockedPIDs sets the GetUserBlockedPIDs handler function, which returns the PIDs blocked by a user
+func (commonProtocol *CommonProtocol) GetUserBlockedPIDs(handler func(pid *types.PID) []*types.PID) {
+	commonProtocol.manager.GetUserBlockedPIDs = handler
 }
 
 // NewCommonProtocol returns a new CommonProtocol
-func NewCommonProtocol(protocol matchmake_extension.Interface) *CommonProtocol {
+func NewCommonProtocol(protocol matchmake_extension.Interface, manager *common_globals.MatchmakingManager) *CommonProtocol {
+	endpoint := protocol.Endpoint().(*nex.PRUDPEndPoint)
+
 	commonProtocol := &CommonProtocol{
-		endpoint: protocol.Endpoint(),
+		endpoint: endpoint,
 		protocol: protocol,
+		manager:  manager,
 	}
 
-	protocol.SetHandlerOpenParticipation(commonProtocol.openParticipation)
-	protocol.SetHandlerCloseParticipation(commonProtocol.closeParticipation)
-	protocol.SetHandlerCreateMatchmakeSession(commonProtocol.createMatchmakeSession)
-	protocol.SetHandlerGetSimplePlayingSession(commonProtocol.getSimplePlayingSession)
-	protocol.SetHandlerAutoMatchmakePostpone(commonProtocol.autoMatchmakePostpone)
-	protocol.SetHandlerAutoMatchmakeWithParamPostpone(commonProtocol.autoMatchmakeWithParamPostpone)
-	protocol.SetHandlerAutoMatchmakeWithSearchCriteriaPostpone(commonProtocol.autoMatchmakeWithSearchCriteriaPostpone)
-	protocol.SetHandlerUpdateProgressScore(commonProtocol.updateProgressScore)
-	protocol.SetHandlerCreateMatchmakeSessionWithParam(commonProtocol.createMatchmakeSessionWithParam)
-	protocol.SetHandlerUpdateApplicationBuffer(commonProtocol.updateApplicationBuffer)
-	protocol.SetHandlerJoinMatchmakeSession(commonProtocol.joinMatchmakeSession)
-	protocol.SetHandlerJoinMatchmakeSessionWithParam(commonProtocol.joinMatchmakeSessionWithParam)
-	protocol.SetHandlerModifyCurrentGameAttribute(commonProtocol.modifyCurrentGameAttribute)
-	protocol.SetHandlerBrowseMatchmakeSession(commonProtocol.browseMatchmakeSession)
-	protocol.SetHandlerJoinMatchmakeSessionEx(commonProtocol.joinMatchmakeSessionEx)
+	protocol.SetHandlerOpenParticipation(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.openParticipation))
+	protocol.SetHandlerCloseParticipation(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.closeParticipation))
+	protocol.SetHandlerCreateMatchmakeSession(common_globals.MeasureHandler3(manager.Metrics(), commonProtocol.createMatchmakeSession))
+	protocol.SetHandlerGetSimplePlayingSession(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.getSimplePlayingSession))
+	protocol.SetHandlerAutoMatchmakePostpone(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.autoMatchmakePostpone))
+	protocol.SetHandlerAutoMatchmakeWithParamPostpone(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.autoMatchmakeWithParamPostpone))
+	protocol.SetHandlerAutoMatchmakeWithSearchCriteriaPostpone(common_globals.MeasureHandler3(manager.Metrics(), commonProtocol.autoMatchmakeWithSearchCriteriaPostpone))
+	protocol.SetHandlerUpdateProgressScore(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.updateProgressScore))
+	protocol.SetHandlerCreateMatchmakeSessionWithParam(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.createMatchmakeSessionWithParam))
+	protocol.SetHandlerUpdateApplicationBuffer(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.updateApplicationBuffer))
+	protocol.SetHandlerJoinMatchmakeSession(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.joinMatchmakeSession))
+	protocol.SetHandlerJoinMatchmakeSessionWithParam(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.joinMatchmakeSessionWithParam))
+	protocol.SetHandlerModifyCurrentGameAttribute(common_globals.MeasureHandler3(manager.Metrics(), commonProtocol.modifyCurrentGameAttribute))
+	protocol.SetHandlerBrowseMatchmakeSession(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.browseMatchmakeSession))
+	protocol.SetHandlerJoinMatchmakeSessionEx(common_globals.MeasureHandler4(manager.Metrics(), commonProtocol.joinMatchmakeSessionEx))
+	protocol.SetHandlerGenerateMatchmakeSessionSystemPassword(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.generateMatchmakeSessionSystemPassword))
+	protocol.SetHandlerClearMatchmakeSessionSystemPassword(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.clearMatchmakeSessionSystemPassword))
+	protocol.SetHandlerCreateCommunity(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.createCommunity))
+	protocol.SetHandlerUpdateCommunity(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.updateCommunity))
+	protocol.SetHandlerFindCommunityByGatheringID(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.findCommunityByGatheringID))
+	protocol.SetHandlerFindOfficialCommunity(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.findOfficialCommunity))
+	protocol.SetHandlerFindCommunityByParticipant(common_globals.MeasureHandler2(manager.Metrics(), commonProtocol.findCommunityByParticipant))
+	protocol.SetHandlerGetSimpleCommunity(common_globals.MeasureHandler1(manager.Metrics(), commonProtocol.getSimpleCommunity))
+
+	endpoint.OnConnectionEnded(func(connection *nex.PRUDPConnection) {
+		manager.ClearBrowseCursor(connection.ID)
+	})
+
+	// * Community sessions can only be joined while the community is open for participation
+	manager.AddSessionJoinCheck(func(_ *types.PID, matchmakeSession *match_making_types.MatchmakeSession) *nex.Error {
+		return commonProtocol.verifyCommunityMatchmakeSession(matchmakeSession)
+	})
 
 	return commonProtocol
 }
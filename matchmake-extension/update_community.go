@@ -0,0 +1,58 @@
+package matchmake_extension
+
+import (
+	"github.com/PretendoNetwork/nex-go/v2"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
+	match_making_types "github.com/PretendoNetwork/nex-protocols-go/v2/match-making/types"
+	matchmake_extension "github.com/PretendoNetwork/nex-protocols-go/v2/matchmake-extension"
+)
+
+func (commonProtocol *CommonProtocol) updateCommunity(err error, packet nex.PacketInterface, callID uint32, community *match_making_types.PersistentGathering) (*nex.RMCMessage, *nex.Error) {
+	if commonProtocol.GetCommunityByGatheringID == nil {
+		common_globals.Logger.Warning("GetCommunityByGatheringID not defined")
+		return nil, nex.NewError(nex.ResultCodes.Core.NotImplemented, "change_error")
+	}
+
+	if commonProtocol.UpdateCommunityByGatheringID == nil {
+		common_globals.Logger.Warning("UpdateCommunityByGatheringID not defined")
+		return nil, nex.NewError(nex.ResultCodes.Core.NotImplemented, "change_error")
+	}
+
+	if err != nil {
+		common_globals.Logger.Error(err.Error())
+		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
+	}
+
+	connection := packet.Sender().(*nex.PRUDPConnection)
+	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
+
+	currentCommunity, errCode := commonProtocol.GetCommunityByGatheringID(community.Gathering.ID.Value)
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	if !currentCommunity.Gathering.OwnerPID.Equals(connection.PID()) {
+		return nil, nex.NewError(nex.ResultCodes.RendezVous.PermissionDenied, "change_error")
+	}
+
+	// * The owner and host can't be changed through an update
+	community.Gathering.OwnerPID = currentCommunity.Gathering.OwnerPID
+	community.Gathering.HostPID = currentCommunity.Gathering.HostPID
+
+	errCode = commonProtocol.UpdateCommunityByGatheringID(community)
+	if errCode != nil {
+		common_globals.Logger.Error(errCode.Error())
+		return nil, errCode
+	}
+
+	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
+	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
+	rmcResponse.MethodID = matchmake_extension.MethodUpdateCommunity
+	rmcResponse.CallID = callID
+
+	if commonProtocol.OnAfterUpdateCommunity != nil {
+		go commonProtocol.OnAfterUpdateCommunity(packet, community)
+	}
+
+	return rmcResponse, nil
+}
@@ -13,7 +13,7 @@ func (commonProtocol *CommonProtocol) updateProgressScore(err error, packet nex.
 		return nil, nex.NewError(nex.ResultCodes.Core.InvalidArgument, "change_error")
 	}
 
-	session, ok := common_globals.GetSession(gid.Value)
+	session, ok := commonProtocol.manager.GetSession(gid.Value)
 	if !ok {
 		return nil, nex.NewError(nex.ResultCodes.RendezVous.SessionVoid, "change_error")
 	}
@@ -30,6 +30,7 @@ func (commonProtocol *CommonProtocol) updateProgressScore(err error, packet nex.
 	}
 
 	session.GameMatchmakeSession.ProgressScore.Value += progressScore.Value
+	session.MarkActivity()
 
 	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
 	rmcResponse.ProtocolID = matchmake_extension.ProtocolID
@@ -3,12 +3,15 @@ package nattraversal
 import (
 	"github.com/PretendoNetwork/nex-go/v2"
 	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	nat_traversal "github.com/PretendoNetwork/nex-protocols-go/v2/nat-traversal"
 )
 
 type CommonProtocol struct {
 	endpoint                              nex.EndpointInterface
 	protocol                              nat_traversal.Interface
+	metrics                               *common_globals.Metrics
+	manager                               *common_globals.MatchmakingManager
 	OnAfterRequestProbeInitiationExt      func(packet nex.PacketInterface, targetList *types.List[*types.String], stationToProbe *types.String)
 	OnAfterReportNATProperties            func(packet nex.PacketInterface, natmapping *types.PrimitiveU32, natfiltering *types.PrimitiveU32, rtt *types.PrimitiveU32)
 	OnAfterReportNATTraversalResult       func(packet nex.PacketInterface, cid *types.PrimitiveU32, result *types.PrimitiveBool, rtt *types.PrimitiveU32)
@@ -16,18 +19,30 @@ type CommonProtocol struct {
 	OnAfterReportNATTraversalResultDetail func(packet nex.PacketInterface, cid *types.PrimitiveU32, result *types.PrimitiveBool, detail *types.PrimitiveS32, rtt *types.PrimitiveU32)
 }
 
+// SetManager sets the MatchmakingManager which the reported NAT properties and traversal results are given to,
+// so they can be used to select session hosts
+func (commonProtocol *CommonProtocol) SetManager(manager *common_globals.MatchmakingManager) {
+	commonProtocol.manager = manager
+}
+
+// SetMetricsRecorder sets the MetricsRecorder receiving the metrics of the handlers. Passing nil disables them
+func (commonProtocol *CommonProtocol) SetMetricsRecorder(recorder common_globals.MetricsRecorder) {
+	commonProtocol.metrics.SetRecorder(recorder)
+}
+
 // NewCommonProtocol returns a new CommonProtocol
 func NewCommonProtocol(protocol nat_traversal.Interface) *CommonProtocol {
 	commonProtocol := &CommonProtocol{
 		endpoint: protocol.Endpoint(),
 		protocol: protocol,
+		metrics:  common_globals.NewMetrics(),
 	}
 
-	protocol.SetHandlerRequestProbeInitiationExt(commonProtocol.requestProbeInitiationExt)
-	protocol.SetHandlerReportNATProperties(commonProtocol.reportNATProperties)
-	protocol.SetHandlerReportNATTraversalResult(commonProtocol.reportNATTraversalResult)
-	protocol.SetHandlerGetRelaySignatureKey(commonProtocol.getRelaySignatureKey)
-	protocol.SetHandlerReportNATTraversalResultDetail(commonProtocol.reportNATTraversalResultDetail)
+	protocol.SetHandlerRequestProbeInitiationExt(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.requestProbeInitiationExt))
+	protocol.SetHandlerReportNATProperties(common_globals.MeasureHandler3(commonProtocol.metrics, commonProtocol.reportNATProperties))
+	protocol.SetHandlerReportNATTraversalResult(common_globals.MeasureHandler3(commonProtocol.metrics, commonProtocol.reportNATTraversalResult))
+	protocol.SetHandlerGetRelaySignatureKey(common_globals.MeasureHandler0(commonProtocol.metrics, commonProtocol.getRelaySignatureKey))
+	protocol.SetHandlerReportNATTraversalResultDetail(common_globals.MeasureHandler4(commonProtocol.metrics, commonProtocol.reportNATTraversalResultDetail))
 
 	return commonProtocol
 }
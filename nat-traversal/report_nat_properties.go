@@ -27,6 +27,10 @@ func (commonProtocol *CommonProtocol) reportNATProperties(err error, packet nex.
 		station.SetPrincipalID(connection.PID())
 	}
 
+	if commonProtocol.manager != nil {
+		commonProtocol.manager.ReportNATProperties(connection, constants.NATMappingProperties(natmapping.Value), constants.NATFilteringProperties(natfiltering.Value), rtt.Value)
+	}
+
 	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
 	rmcResponse.ProtocolID = nat_traversal.ProtocolID
 	rmcResponse.MethodID = nat_traversal.MethodReportNATProperties
@@ -16,6 +16,10 @@ func (commonProtocol *CommonProtocol) reportNATTraversalResult(err error, packet
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
 
+	if commonProtocol.manager != nil {
+		commonProtocol.manager.ReportNATTraversalResult(connection, cid.Value, result.Value, rtt.Value)
+	}
+
 	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
 	rmcResponse.ProtocolID = nat_traversal.ProtocolID
 	rmcResponse.MethodID = nat_traversal.MethodReportNATTraversalResult
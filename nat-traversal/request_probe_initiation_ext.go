@@ -2,7 +2,6 @@ package nattraversal
 
 import (
 	"github.com/PretendoNetwork/nex-go/v2"
-	"github.com/PretendoNetwork/nex-go/v2/constants"
 	"github.com/PretendoNetwork/nex-go/v2/types"
 	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	nat_traversal "github.com/PretendoNetwork/nex-protocols-go/v2/nat-traversal"
@@ -16,7 +15,6 @@ func (commonProtocol *CommonProtocol) requestProbeInitiationExt(err error, packe
 
 	connection := packet.Sender().(*nex.PRUDPConnection)
 	endpoint := connection.Endpoint().(*nex.PRUDPEndPoint)
-	server := endpoint.Server
 
 	rmcResponse := nex.NewRMCSuccess(endpoint, nil)
 	rmcResponse.ProtocolID = nat_traversal.ProtocolID
@@ -35,8 +33,6 @@ func (commonProtocol *CommonProtocol) requestProbeInitiationExt(err error, packe
 	rmcRequest.MethodID = nat_traversal.MethodInitiateProbe
 	rmcRequest.Parameters = rmcRequestBody
 
-	rmcRequestBytes := rmcRequest.Bytes()
-
 	for _, target := range targetList.Slice() {
 		targetStation := types.NewStationURL(target.Value)
 
@@ -47,24 +43,10 @@ func (commonProtocol *CommonProtocol) requestProbeInitiationExt(err error, packe
 				continue
 			}
 
-			var messagePacket nex.PRUDPPacketInterface
-
-			if target.DefaultPRUDPVersion == 0 {
-				messagePacket, _ = nex.NewPRUDPPacketV0(server, target, nil)
-			} else {
-				messagePacket, _ = nex.NewPRUDPPacketV1(server, target, nil)
+			err := common_globals.SendRMCRequest(endpoint, target, rmcRequest)
+			if err != nil {
+				common_globals.Logger.Warning(err.Error())
 			}
-
-			messagePacket.SetType(constants.DataPacket)
-			messagePacket.AddFlag(constants.PacketFlagNeedsAck)
-			messagePacket.AddFlag(constants.PacketFlagReliable)
-			messagePacket.SetSourceVirtualPortStreamType(target.StreamType)
-			messagePacket.SetSourceVirtualPortStreamID(endpoint.StreamID)
-			messagePacket.SetDestinationVirtualPortStreamType(target.StreamType)
-			messagePacket.SetDestinationVirtualPortStreamID(target.StreamID)
-			messagePacket.SetPayload(rmcRequestBytes)
-
-			server.Send(messagePacket)
 		}
 	}
 
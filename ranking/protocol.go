@@ -3,6 +3,7 @@ package ranking
 import (
 	"github.com/PretendoNetwork/nex-go/v2"
 	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	ranking "github.com/PretendoNetwork/nex-protocols-go/v2/ranking"
 	ranking_types "github.com/PretendoNetwork/nex-protocols-go/v2/ranking/types"
 )
@@ -10,6 +11,7 @@ import (
 type CommonProtocol struct {
 	endpoint                                          nex.EndpointInterface
 	protocol                                          ranking.Interface
+	metrics                                           *common_globals.Metrics
 	GetCommonData                                     func(uniqueID *types.PrimitiveU64) (*types.Buffer, error)
 	UploadCommonData                                  func(pid *types.PID, uniqueID *types.PrimitiveU64, commonData *types.Buffer) error
 	InsertRankingByPIDAndRankingScoreData             func(pid *types.PID, rankingScoreData *ranking_types.RankingScoreData, uniqueID *types.PrimitiveU64) error
@@ -22,19 +24,25 @@ type CommonProtocol struct {
 	OnAfterUploadScore                                func(packet nex.PacketInterface, scoreData *ranking_types.RankingScoreData, uniqueID *types.PrimitiveU64)
 }
 
+// SetMetricsRecorder sets the MetricsRecorder receiving the metrics of the handlers. Passing nil disables them
+func (commonProtocol *CommonProtocol) SetMetricsRecorder(recorder common_globals.MetricsRecorder) {
+	commonProtocol.metrics.SetRecorder(recorder)
+}
+
 // NewCommonProtocol returns a new CommonProtocol
 func NewCommonProtocol(protocol ranking.Interface) *CommonProtocol {
 	commonProtocol := &CommonProtocol{
 		endpoint: protocol.Endpoint(),
 		protocol: protocol,
+		metrics:  common_globals.NewMetrics(),
 	}
 
-	protocol.SetHandlerGetCachedTopXRanking(commonProtocol.getCachedTopXRanking)
-	protocol.SetHandlerGetCachedTopXRankings(commonProtocol.getCachedTopXRankings)
-	protocol.SetHandlerGetCommonData(commonProtocol.getCommonData)
-	protocol.SetHandlerGetRanking(commonProtocol.getRanking)
-	protocol.SetHandlerUploadCommonData(commonProtocol.uploadCommonData)
-	protocol.SetHandlerUploadScore(commonProtocol.uploadScore)
+	protocol.SetHandlerGetCachedTopXRanking(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.getCachedTopXRanking))
+	protocol.SetHandlerGetCachedTopXRankings(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.getCachedTopXRankings))
+	protocol.SetHandlerGetCommonData(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.getCommonData))
+	protocol.SetHandlerGetRanking(common_globals.MeasureHandler5(commonProtocol.metrics, commonProtocol.getRanking))
+	protocol.SetHandlerUploadCommonData(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.uploadCommonData))
+	protocol.SetHandlerUploadScore(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.uploadScore))
 
 	return commonProtocol
 }
@@ -3,28 +3,36 @@ package secureconnection
 import (
 	"github.com/PretendoNetwork/nex-go/v2"
 	"github.com/PretendoNetwork/nex-go/v2/types"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	secure_connection "github.com/PretendoNetwork/nex-protocols-go/v2/secure-connection"
 )
 
 type CommonProtocol struct {
 	endpoint             nex.EndpointInterface
 	protocol             secure_connection.Interface
+	metrics              *common_globals.Metrics
 	CreateReportDBRecord func(pid *types.PID, reportID *types.PrimitiveU32, reportData *types.QBuffer) error
 	OnAfterRegister      func(packet nex.PacketInterface, vecMyURLs *types.List[*types.StationURL])
 	OnAfterReplaceURL    func(packet nex.PacketInterface, target *types.StationURL, url *types.StationURL)
 	OnAfterSendReport    func(packet nex.PacketInterface, reportID *types.PrimitiveU32, reportData *types.QBuffer)
 }
 
+// SetMetricsRecorder sets the MetricsRecorder receiving the metrics of the handlers. Passing nil disables them
+func (commonProtocol *CommonProtocol) SetMetricsRecorder(recorder common_globals.MetricsRecorder) {
+	commonProtocol.metrics.SetRecorder(recorder)
+}
+
 // NewCommonProtocol returns a new CommonProtocol
 func NewCommonProtocol(protocol secure_connection.Interface) *CommonProtocol {
 	commonProtocol := &CommonProtocol{
 		endpoint: protocol.Endpoint(),
 		protocol: protocol,
+		metrics:  common_globals.NewMetrics(),
 	}
 
-	protocol.SetHandlerRegister(commonProtocol.register)
-	protocol.SetHandlerReplaceURL(commonProtocol.replaceURL)
-	protocol.SetHandlerSendReport(commonProtocol.sendReport)
+	protocol.SetHandlerRegister(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.register))
+	protocol.SetHandlerReplaceURL(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.replaceURL))
+	protocol.SetHandlerSendReport(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.sendReport))
 
 	return commonProtocol
 }
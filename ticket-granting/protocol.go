@@ -11,6 +11,7 @@ import (
 
 type CommonProtocol struct {
 	protocol                   ticket_granting.Interface
+	metrics                    *common_globals.Metrics
 	SecureStationURL           *types.StationURL
 	SpecialProtocols           []*types.PrimitiveU8
 	StationURLSpecialProtocols *types.StationURL
@@ -32,10 +33,16 @@ func (commonProtocol *CommonProtocol) EnableInsecureLogin() {
 	commonProtocol.allowInsecureLoginMethod = true
 }
 
+// SetMetricsRecorder sets the MetricsRecorder receiving the metrics of the handlers. Passing nil disables them
+func (commonProtocol *CommonProtocol) SetMetricsRecorder(recorder common_globals.MetricsRecorder) {
+	commonProtocol.metrics.SetRecorder(recorder)
+}
+
 // NewCommonProtocol returns a new CommonProtocol
 func NewCommonProtocol(protocol ticket_granting.Interface) *CommonProtocol {
 	commonProtocol := &CommonProtocol{
 		protocol:                   protocol,
+		metrics:                    common_globals.NewMetrics(),
 		SecureStationURL:           types.NewStationURL("prudp:/"),
 		SpecialProtocols:           make([]*types.PrimitiveU8, 0),
 		StationURLSpecialProtocols: types.NewStationURL(""),
@@ -44,9 +51,9 @@ func NewCommonProtocol(protocol ticket_granting.Interface) *CommonProtocol {
 		SessionKeyLength:           32,
 	}
 
-	protocol.SetHandlerLogin(commonProtocol.login)
-	protocol.SetHandlerLoginEx(commonProtocol.loginEx)
-	protocol.SetHandlerRequestTicket(commonProtocol.requestTicket)
+	protocol.SetHandlerLogin(common_globals.MeasureHandler1(commonProtocol.metrics, commonProtocol.login))
+	protocol.SetHandlerLoginEx(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.loginEx))
+	protocol.SetHandlerRequestTicket(common_globals.MeasureHandler2(commonProtocol.metrics, commonProtocol.requestTicket))
 
 	commonProtocol.DisableInsecureLogin() // * Disable insecure login by default
 
@@ -2,24 +2,32 @@ package utility
 
 import (
 	"github.com/PretendoNetwork/nex-go/v2"
+	common_globals "github.com/PretendoNetwork/nex-protocols-common-go/v2/globals"
 	utility "github.com/PretendoNetwork/nex-protocols-go/v2/utility"
 )
 
 type CommonProtocol struct {
 	endpoint                  nex.EndpointInterface
 	protocol                  utility.Interface
+	metrics                   *common_globals.Metrics
 	GenerateNEXUniqueID       func() uint64
 	OnAfterAcquireNexUniqueID func(packet nex.PacketInterface)
 }
 
+// SetMetricsRecorder sets the MetricsRecorder receiving the metrics of the handlers. Passing nil disables them
+func (commonProtocol *CommonProtocol) SetMetricsRecorder(recorder common_globals.MetricsRecorder) {
+	commonProtocol.metrics.SetRecorder(recorder)
+}
+
 // NewCommonProtocol returns a new CommonProtocol
 func NewCommonProtocol(protocol utility.Interface) *CommonProtocol {
 	commonProtocol := &CommonProtocol{
 		endpoint: protocol.Endpoint(),
 		protocol: protocol,
+		metrics:  common_globals.NewMetrics(),
 	}
 
-	protocol.SetHandlerAcquireNexUniqueID(commonProtocol.acquireNexUniqueID)
+	protocol.SetHandlerAcquireNexUniqueID(common_globals.MeasureHandler0(commonProtocol.metrics, commonProtocol.acquireNexUniqueID))
 
 	return commonProtocol
 }